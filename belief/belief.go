@@ -0,0 +1,275 @@
+// Package belief tracks what one player can infer about the cards held by their opponents.
+package belief
+
+import (
+	"love-letter-ai/rules"
+)
+
+// Distribution maps each card to the probability that a player holds it. Cards that can't be held are left out.
+type Distribution map[rules.Card]float64
+
+// MostLikely returns the most likely card and its probability. Ties go to the card registered first in the CardSet.
+func (dist Distribution) MostLikely(cards *rules.CardSet) (rules.Card, float64) {
+	best, bestP := rules.None, 0.0
+	for _, card := range cards.Cards() {
+		if dist[card] > bestP {
+			best, bestP = card, dist[card]
+		}
+	}
+	return best, bestP
+}
+
+// Tracker maintains one player's beliefs about every opponent's card, from the events that player can see.
+// Every unseen card is considered equally likely, except that cards the player has seen (with a Priest, Baron or King)
+// are certain, and cards wrongly guessed by a Guard are ruled out until the guessed player changes their card.
+type Tracker struct {
+	// Player is the id of the player whose beliefs are tracked.
+	Player int
+
+	cards *rules.CardSet
+
+	// seen counts every public card (face-up and discarded).
+	seen rules.Deck
+
+	// faceup and hand are the face-up cards, and the card(s) the player holds.
+	faceup rules.Stack
+	hand   rules.Stack
+
+	known      rules.Stack
+	excluded   []map[rules.Card]bool
+	eliminated []bool
+
+	// lastPlayed is the last card played, which decides how a guess is matched.
+	lastPlayed rules.Card
+
+	// princeDiscard is the last event if it was a PrinceDiscard. If the card discarded eliminates its player (like the
+	// Princess), the Eliminated event that follows has the same card, which has already been counted.
+	princeDiscard *rules.PrinceDiscard
+
+	// observed is the number of events from a view's log that have been observed.
+	observed int
+}
+
+// NewTracker returns a Tracker for the player in a game with the provided cards.
+func NewTracker(cards *rules.CardSet, numPlayers, player int) *Tracker {
+	return &Tracker{
+		Player:     player,
+		cards:      cards,
+		known:      make(rules.Stack, numPlayers),
+		excluded:   make([]map[rules.Card]bool, numPlayers),
+		eliminated: make([]bool, numPlayers),
+	}
+}
+
+// NewTrackerFromView returns a Tracker for the player whose view is provided, which has observed the view.
+func NewTrackerFromView(view rules.PlayerView) (*Tracker, error) {
+	cards, err := rules.CardSetNamed(view.CardSet)
+	if err != nil {
+		return nil, err
+	}
+	tracker := NewTracker(cards, view.NumPlayers, view.Player)
+	tracker.Update(view)
+	return tracker, nil
+}
+
+// Update observes every event in the view's log that hasn't been observed yet, and updates the player's cards.
+// The view must be for the same player and game each time.
+func (tracker *Tracker) Update(view rules.PlayerView) {
+	for _, event := range view.Log.Events[tracker.observed:] {
+		tracker.Observe(event)
+	}
+	tracker.observed = len(view.Log.Events)
+	tracker.SetFaceup(view.Faceup)
+	tracker.SetHand(append(view.Hand.Copy(), view.PendingDraws...))
+}
+
+// SetHand records the cards the player holds (including any cards they're choosing between, e.g. for a Chancellor).
+func (tracker *Tracker) SetHand(hand rules.Stack) {
+	tracker.hand = hand.Copy()
+}
+
+// SetFaceup records the cards dealt face-up to no one.
+func (tracker *Tracker) SetFaceup(faceup rules.Stack) {
+	tracker.faceup = faceup.Copy()
+}
+
+// Observe updates the beliefs from an event the player can see.
+func (tracker *Tracker) Observe(event rules.Event) {
+	if !event.VisibleTo(tracker.Player) {
+		return
+	}
+	princeDiscard := tracker.princeDiscard
+	tracker.princeDiscard = nil
+
+	switch e := event.(type) {
+	case rules.CardPlayed:
+		tracker.seen[e.Card]++
+		tracker.lastPlayed = e.Card
+		if e.Player == tracker.Player {
+			tracker.hand = without(tracker.hand, e.Card)
+			return
+		}
+		if tracker.known[e.Player] == e.Card {
+			// They played the card we knew about, so they kept the card they drew
+			tracker.known[e.Player] = rules.None
+		}
+		if _, ok := tracker.cards.Definition(e.Card).(rules.Resolver); ok {
+			// They choose which card to keep (e.g. with a Chancellor), and might not keep the one we knew about
+			tracker.known[e.Player] = rules.None
+		}
+		// They might have kept the card they drew, so a wrong guess no longer applies
+		tracker.excluded[e.Player] = nil
+	case rules.GuardGuess:
+		if e.Correct || e.Target == tracker.Player {
+			return
+		}
+		if tracker.excluded[e.Target] == nil {
+			tracker.excluded[e.Target] = map[rules.Card]bool{}
+		}
+		for _, card := range tracker.guessedCards(e.Guess) {
+			tracker.excluded[e.Target][card] = true
+		}
+	case rules.PriestReveal:
+		tracker.known[e.Target] = e.Card
+	case rules.BaronCompare:
+		if e.Player == tracker.Player {
+			tracker.known[e.Target] = e.TargetCard
+		} else if e.Target == tracker.Player {
+			tracker.known[e.Player] = e.PlayerCard
+		}
+	case rules.PrinceDiscard:
+		tracker.princeDiscard = &e
+		tracker.seen[e.Card]++
+		if e.Player == tracker.Player {
+			tracker.hand = without(tracker.hand, e.Card)
+			return
+		}
+		tracker.known[e.Player] = rules.None
+		tracker.excluded[e.Player] = nil
+	case rules.KingSwap:
+		switch tracker.Player {
+		case e.Player:
+			tracker.swapWithPlayer(e.Target)
+		case e.Target:
+			tracker.swapWithPlayer(e.Player)
+		default:
+			tracker.known[e.Player], tracker.known[e.Target] = tracker.known[e.Target], tracker.known[e.Player]
+			tracker.excluded[e.Player], tracker.excluded[e.Target] = tracker.excluded[e.Target], tracker.excluded[e.Player]
+		}
+	case rules.Eliminated:
+		discarded := princeDiscard != nil && princeDiscard.Player == e.Player && princeDiscard.Card == e.Card
+		if e.Card != rules.None && !discarded {
+			tracker.seen[e.Card]++
+		}
+		tracker.eliminated[e.Player] = true
+		if e.Player == tracker.Player {
+			if !discarded {
+				tracker.hand = without(tracker.hand, e.Card)
+			}
+			return
+		}
+		tracker.known[e.Player] = rules.None
+		tracker.excluded[e.Player] = nil
+	}
+}
+
+// swapWithPlayer records that the player traded hands with another player. The other player now holds the player's
+// card, and the player's new card is unknown until SetHand is called.
+func (tracker *Tracker) swapWithPlayer(other int) {
+	tracker.known[other] = rules.None
+	if len(tracker.hand) == 1 {
+		tracker.known[other] = tracker.hand[0]
+	}
+	tracker.excluded[other] = nil
+	tracker.hand = nil
+}
+
+// guessedCards returns the cards ruled out by a wrong guess, which depends on whether the last card played guesses
+// by value (like the Premium Guard).
+func (tracker *Tracker) guessedCards(guess rules.Card) []rules.Card {
+	if _, ok := tracker.cards.Definition(tracker.lastPlayed).(rules.Guesser); !ok {
+		return []rules.Card{guess}
+	}
+	guessed := []rules.Card{}
+	for _, card := range tracker.cards.Cards() {
+		if tracker.cards.Value(card) == tracker.cards.Value(guess) {
+			guessed = append(guessed, card)
+		}
+	}
+	return guessed
+}
+
+// Unseen returns the number of copies of each card that the player hasn't seen, including cards held by opponents
+// whose cards the player knows.
+func (tracker *Tracker) Unseen() rules.Deck {
+	unseen := tracker.cards.Deck()
+	for card, count := range tracker.seen {
+		unseen[card] -= count
+	}
+	for _, card := range append(tracker.faceup.Copy(), tracker.hand...) {
+		if card != rules.None {
+			unseen[card]--
+		}
+	}
+	for card := range unseen {
+		if unseen[card] < 0 {
+			unseen[card] = 0
+		}
+	}
+	return unseen
+}
+
+// Distribution returns the probability of each card being held by the provided player. It's empty for the tracked
+// player and for eliminated players.
+func (tracker *Tracker) Distribution(player int) Distribution {
+	dist := Distribution{}
+	if player == tracker.Player || tracker.eliminated[player] {
+		return dist
+	}
+	if tracker.known[player] != rules.None {
+		dist[tracker.known[player]] = 1
+		return dist
+	}
+
+	unseen := tracker.Unseen()
+	for pid, card := range tracker.known {
+		if pid != player && card != rules.None && unseen[card] > 0 {
+			// Another opponent holds this card
+			unseen[card]--
+		}
+	}
+
+	weights := unseen
+	total := 0
+	for card := range unseen {
+		if tracker.excluded[player][rules.Card(card)] {
+			weights[card] = 0
+		}
+		total += weights[card]
+	}
+	if total == 0 {
+		// The guesses are inconsistent with what's left (e.g. the player was given a new card we didn't see)
+		weights = tracker.Unseen()
+		total = weights.Size()
+	}
+	for card, count := range weights {
+		if count > 0 && rules.Card(card) != rules.None {
+			dist[rules.Card(card)] = float64(count) / float64(total)
+		}
+	}
+	return dist
+}
+
+// without returns a copy of the stack with the first copy of the card removed.
+func without(stack rules.Stack, card rules.Card) rules.Stack {
+	result := make(rules.Stack, 0, len(stack))
+	removed := false
+	for _, val := range stack {
+		if val == card && !removed {
+			removed = true
+			continue
+		}
+		result = append(result, val)
+	}
+	return result
+}
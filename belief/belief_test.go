@@ -0,0 +1,142 @@
+package belief
+
+import (
+	"math/rand"
+	"testing"
+
+	"love-letter-ai/rules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackerCountsCards(t *testing.T) {
+	tracker := NewTracker(rules.ClassicCards, 2, 0)
+	tracker.SetHand(rules.Stack{rules.Guard})
+	tracker.SetFaceup(rules.Stack{rules.Priest, rules.Baron, rules.Handmaid})
+	tracker.Observe(rules.CardPlayed{Player: 1, Card: rules.Guard, Target: 0, SecondTarget: -1})
+
+	// 3 Guards, and one of every other card but 2 Princes, are left
+	dist := tracker.Distribution(1)
+	assert.InDelta(t, 3.0/11, dist[rules.Guard], 1e-9)
+	assert.InDelta(t, 2.0/11, dist[rules.Prince], 1e-9)
+	assert.InDelta(t, 1.0/11, dist[rules.Princess], 1e-9)
+	assert.Empty(t, tracker.Distribution(0))
+
+	total := 0.0
+	for _, p := range dist {
+		total += p
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+}
+
+func TestTrackerExcludesFailedGuesses(t *testing.T) {
+	tracker := NewTracker(rules.ClassicCards, 2, 0)
+	tracker.SetHand(rules.Stack{rules.Guard, rules.Guard})
+	tracker.Observe(rules.CardPlayed{Player: 0, Card: rules.Guard, Target: 1, SecondTarget: -1})
+	tracker.Observe(rules.GuardGuess{Player: 0, Target: 1, Guess: rules.Princess})
+
+	dist := tracker.Distribution(1)
+	assert.Zero(t, dist[rules.Princess])
+	assert.InDelta(t, 2.0/13, dist[rules.Prince], 1e-9)
+
+	// Player 1 might keep the card they draw
+	tracker.Observe(rules.CardPlayed{Player: 1, Card: rules.Handmaid, Target: -1, SecondTarget: -1})
+	assert.InDelta(t, 1.0/13, tracker.Distribution(1)[rules.Princess], 1e-9)
+}
+
+func TestTrackerFollowsKnownCards(t *testing.T) {
+	tracker := NewTracker(rules.ClassicCards, 3, 0)
+	tracker.SetHand(rules.Stack{rules.Priest, rules.Guard})
+	tracker.Observe(rules.CardPlayed{Player: 0, Card: rules.Priest, Target: 1, SecondTarget: -1})
+	tracker.Observe(rules.PriestReveal{Player: 0, Target: 1, Card: rules.Countess})
+	assert.Equal(t, Distribution{rules.Countess: 1}, tracker.Distribution(1))
+	assert.Zero(t, tracker.Distribution(2)[rules.Countess], "Player 1 holds the only Countess")
+
+	// Player 1 keeps the Countess and gives it away
+	tracker.Observe(rules.CardPlayed{Player: 1, Card: rules.King, Target: 2, SecondTarget: -1})
+	tracker.Observe(rules.KingSwap{Player: 1, Target: 2})
+	assert.Equal(t, Distribution{rules.Countess: 1}, tracker.Distribution(2))
+	assert.NotEqual(t, 1.0, tracker.Distribution(1)[rules.Countess])
+
+	card, p := tracker.Distribution(2).MostLikely(rules.ClassicCards)
+	assert.Equal(t, rules.Countess, card)
+	assert.Equal(t, 1.0, p)
+
+	// A Prince makes player 2 discard it, so the Countess is gone
+	tracker.Observe(rules.CardPlayed{Player: 1, Card: rules.Prince, Target: 2, SecondTarget: -1})
+	tracker.Observe(rules.PrinceDiscard{Player: 2, Card: rules.Countess})
+	assert.Zero(t, tracker.Distribution(2)[rules.Countess])
+	assert.NotEmpty(t, tracker.Distribution(2))
+}
+
+func TestTrackerKnowsWhatItGaveAway(t *testing.T) {
+	tracker := NewTracker(rules.ClassicCards, 2, 0)
+	tracker.SetHand(rules.Stack{rules.King, rules.Princess})
+	tracker.Observe(rules.CardPlayed{Player: 0, Card: rules.King, Target: 1, SecondTarget: -1})
+	tracker.Observe(rules.KingSwap{Player: 0, Target: 1})
+	assert.Equal(t, Distribution{rules.Princess: 1}, tracker.Distribution(1))
+
+	tracker.Observe(rules.Eliminated{Player: 1, Cause: rules.EliminatedByDiscard, Card: rules.Princess})
+	assert.Empty(t, tracker.Distribution(1))
+}
+
+func TestTrackerCountsPrinceOnPrincessOnce(t *testing.T) {
+	tracker := NewTracker(rules.ClassicCards, 3, 0)
+	tracker.SetHand(rules.Stack{rules.Guard})
+	tracker.Observe(rules.CardPlayed{Player: 1, Card: rules.Prince, Target: 2, SecondTarget: -1})
+	tracker.Observe(rules.PrinceDiscard{Player: 2, Card: rules.Princess})
+	tracker.Observe(rules.Eliminated{Player: 2, Cause: rules.EliminatedByDiscard, Card: rules.Princess})
+
+	assert.Equal(t, 1, tracker.seen[rules.Princess])
+	assert.Equal(t, rules.ClassicCards.Deck().Size()-3, tracker.Unseen().Size())
+	assert.Empty(t, tracker.Distribution(2))
+
+	// The tracked player's own Princess is removed from their hand once
+	own := NewTracker(rules.ClassicCards, 3, 2)
+	own.SetHand(rules.Stack{rules.Princess})
+	own.Observe(rules.PrinceDiscard{Player: 2, Card: rules.Princess})
+	own.Observe(rules.Eliminated{Player: 2, Cause: rules.EliminatedByDiscard, Card: rules.Princess})
+	assert.Equal(t, 1, own.seen[rules.Princess])
+	assert.Empty(t, own.hand)
+
+	// An elimination that doesn't follow the discard is counted
+	other := NewTracker(rules.ClassicCards, 3, 0)
+	other.Observe(rules.PrinceDiscard{Player: 1, Card: rules.Guard})
+	other.Observe(rules.Eliminated{Player: 2, Cause: rules.EliminatedByGuess, Card: rules.Princess})
+	assert.Equal(t, 1, other.seen[rules.Princess])
+}
+
+func TestTrackerIsConsistentWithGames(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, cards := range []*rules.CardSet{rules.ClassicCards, rules.Edition2019Cards, rules.PremiumCards} {
+		for game := 0; game < 100; game++ {
+			numPlayers := cards.MinPlayers + game%(cards.MaxPlayers-cards.MinPlayers+1)
+			state, err := rules.NewGameWithCards(cards, numPlayers, r)
+			if !assert.NoError(t, err) {
+				return
+			}
+			trackers := make([]*Tracker, numPlayers)
+			for pid := range trackers {
+				trackers[pid] = NewTracker(cards, numPlayers, pid)
+			}
+			for !state.GameEnded {
+				for pid, tracker := range trackers {
+					tracker.Update(state.ViewFor(pid))
+					for opponent := range trackers {
+						if opponent == pid || state.EliminatedPlayers[opponent] {
+							continue
+						}
+						// The opponent's card is always possible, and it's the only possibility when it's known
+						dist := tracker.Distribution(opponent)
+						assert.True(t, dist[state.CardInHand[opponent]] > 0, "%s: player %d's card is possible", cards.Name, opponent)
+						if len(dist) == 1 {
+							assert.Equal(t, Distribution{state.CardInHand[opponent]: 1}, dist)
+						}
+					}
+				}
+				acts := state.LegalActions()
+				state.PlayCard(acts[r.Intn(len(acts))], r)
+			}
+		}
+	}
+}
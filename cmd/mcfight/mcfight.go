@@ -7,8 +7,10 @@ import (
 	"love-letter-ai/montecarlo"
 	"love-letter-ai/players"
 	"love-letter-ai/rules"
+	"love-letter-ai/state"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var loadPath = flag.String("load", "", "Path to the file to load weights")
@@ -19,19 +21,26 @@ var nEpochs = flag.Int("epochs", 5, "Number of epochs")
 var nTraces = flag.Int("traces", 20, "Number of game traces to print after each epoch")
 var nGames = flag.Int("games", 1000000000, "Number of games per training epoch")
 var nTest = flag.Int("n", 1000, "Number of games played in each test against random")
+var validate = flag.Bool("validate", false, "Check that every game is valid after each play (slow)")
+var encoderName = flag.String("encoder", "simple", "Name of the state encoder ("+strings.Join(state.EncoderNames(), ", ")+")")
+var memory = flag.Int("memory", 0, "Most megabytes used by each table of values, or 0 for no limit (tables that don't fit are stored sparsely)")
 
 func main() {
 	flag.Parse()
+	rules.ValidateEveryPlay = *validate
 
-	pl := montecarlo.NewQPlayer(float32(*epsilon))
-	var err error
+	enc, err := state.EncoderNamed(*encoderName)
+	if err != nil {
+		panic(err)
+	}
+	pl := montecarlo.NewQPlayerWithMemory(enc, float32(*epsilon), *memory<<20)
 
 	if *loadPath != "" {
 		err = pl.LoadFromFile(*loadPath)
 		if err != nil {
 			// Okay, no file, print a warning and keep going
 			fmt.Println("WARNING: Could not find the file you wanted to load, so proceeding with newly initialized MC")
-			pl = montecarlo.NewQPlayer(float32(*epsilon))
+			pl = montecarlo.NewQPlayerWithMemory(enc, float32(*epsilon), *memory<<20)
 		} else {
 			fmt.Println("The weights were loaded from '" + *loadPath + "'")
 		}
@@ -74,7 +83,7 @@ func main() {
 func printTraces(n int, pl *montecarlo.QPlayer) {
 	fists := make([]rules.FinalState, 0, n)
 	for i := 0; i < n; i++ {
-		tr, err := gamemaster.TraceOneGame(&players.RandomPlayer{})
+		tr, err := gamemaster.TraceOneGameEncoded(&players.RandomPlayer{}, 2, pl.Encoder())
 		if err != nil {
 			panic(err.Error())
 		}
@@ -92,17 +101,15 @@ func printTraces(n int, pl *montecarlo.QPlayer) {
 }
 
 func fightRandom(n int, pl *montecarlo.QPlayer) {
-	fmt.Printf("MC playing 1st has a win rate of %2.1f%%\n", fightPlayers(n, []players.Player{
-		pl,
-		&players.RandomPlayer{},
-	}))
-	fmt.Printf("MC playing 2nd has a win rate of %2.1f%%\n", 100.0-fightPlayers(n, []players.Player{
-		&players.RandomPlayer{},
-		pl,
-	}))
+	first, illegalFirst, playsFirst := fightPlayers(n, []players.Player{pl, &players.RandomPlayer{}})
+	second, illegalSecond, playsSecond := fightPlayers(n, []players.Player{&players.RandomPlayer{}, pl})
+	fmt.Printf("MC playing 1st has a win rate of %2.1f%%\n", first)
+	fmt.Printf("MC playing 2nd has a win rate of %2.1f%%\n", 100.0-second)
+	fmt.Printf("%d of MC's %d picks were illegal\n", illegalFirst[0]+illegalSecond[1], playsFirst[0]+playsSecond[1])
 }
 
-func fightPlayers(n int, pls []players.Player) float32 {
+// fightPlayers returns player 0's win rate, and the number of illegal plays and plays by each player.
+func fightPlayers(n int, pls []players.Player) (float32, []int, []int) {
 	// Now fight vs Random
 	gm, err := gamemaster.New(pls)
 	if err != nil {
@@ -113,5 +120,5 @@ func fightPlayers(n int, pls []players.Player) float32 {
 		panic(err)
 	}
 
-	return float32(wins) / float32(n) * 100.0
+	return float32(wins) / float32(n) * 100.0, gm.IllegalPlays, gm.Plays
 }
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"love-letter-ai/gamemaster"
 	"love-letter-ai/players"
+	"love-letter-ai/rules"
 	"math/rand"
 	"time"
 )
 
 var nTest = flag.Int("n", 1000, "Number of games played in each test against random")
+var validate = flag.Bool("validate", false, "Check that every game is valid after each play (slow)")
 
 func main() {
 	flag.Parse()
+	rules.ValidateEveryPlay = *validate
 	rand.Seed(time.Now().UnixNano())
 
 	pls := []players.Player{
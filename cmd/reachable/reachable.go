@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"love-letter-ai/rules"
+	"love-letter-ai/state"
+)
+
+var (
+	nGames    = flag.Int("games", 100000, "Number of random games played to check that every state reached is in the compact states")
+	canonical = flag.Bool("canonical", false, "Number only the states with the cards in hand in canonical order")
+)
+
+func main() {
+	flag.Parse()
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	start := time.Now()
+	enc := state.Compact
+	if *canonical {
+		enc = state.CanonicalCompact
+	}
+	hash := enc.Hash()
+	fmt.Printf("Built a perfect hash of %d reachable states (%.1f%% of %d) in %v, using %d MB\n",
+		hash.Len(), float64(hash.Len())*100/state.SpaceMagnitude, state.SpaceMagnitude, time.Since(start), hash.Bytes()>>20)
+	fmt.Printf("A dense table of float32 state-action values needs %d MB, instead of %d MB\n",
+		state.ActionSize(enc)*4>>20, state.ActionSpaceMagnitude*4>>20)
+
+	seen := map[int]bool{}
+	missing := 0
+	for i := 0; i < *nGames; i++ {
+		game, err := rules.NewGame(2+i%3, r)
+		if err != nil {
+			panic(err)
+		}
+		for !game.GameEnded {
+			st := enc.Index(game.ViewFor(game.ActivePlayer))
+			if st >= hash.Len() {
+				missing++
+				fmt.Printf("This state isn't in the compact states:\n%s\n", game.Scenario())
+			}
+			seen[st] = true
+
+			acts := game.LegalActions()
+			game.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+	}
+	fmt.Printf("%d random games reached %d states (%.1f%%), and %d states weren't in the compact states\n",
+		*nGames, len(seen), float64(len(seen))*100/float64(hash.Len()), missing)
+}
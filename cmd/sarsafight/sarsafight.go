@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"love-letter-ai/gamemaster"
 	"love-letter-ai/players"
@@ -26,19 +27,27 @@ var nEpochs = flag.Int("epochs", 5, "Number of epochs")
 var nTraces = flag.Int("traces", 2, "Number of game traces to print after each epoch")
 var nGames = flag.Int("games", 1000000, "Number of games per training epoch")
 var nTest = flag.Int("n", 10000, "Number of games played in each test against random")
+var validate = flag.Bool("validate", false, "Check that every game is valid after each play (slow)")
+var encoderName = flag.String("encoder", "simple", "Name of the state encoder ("+strings.Join(state.EncoderNames(), ", ")+")")
+var memory = flag.Int("memory", 0, "Most megabytes used by each table of values, or 0 for no limit (tables that don't fit are stored sparsely)")
+var opponentPath = flag.String("opponent", "", "Path to the file with the weights of another Sarsa player to fight after training")
 
 func main() {
 	flag.Parse()
+	rules.ValidateEveryPlay = *validate
 
-	sar := td.NewTD(float32(*alpha), float32(*gamma))
-	var err error
+	enc, err := state.EncoderNamed(*encoderName)
+	if err != nil {
+		panic(err)
+	}
+	sar := td.NewTDWithMemory(enc, float32(*alpha), float32(*gamma), *memory<<20)
 
 	if *loadPath != "" {
 		err = sar.LoadFromFile(*loadPath)
 		if err != nil {
 			// Okay, no file, print a warning and keep going
 			fmt.Println("WARNING: Could not find the file you wanted to load, so proceeding with newly initialized SARSA")
-			sar = td.NewTD(float32(*alpha), float32(*gamma))
+			sar = td.NewTDWithMemory(enc, float32(*alpha), float32(*gamma), *memory<<20)
 		} else {
 			fmt.Println("The weights were loaded from '" + *loadPath + "'")
 		}
@@ -72,10 +81,24 @@ func main() {
 		}
 	}
 
+	if stored, dropped := sar.Stored(); dropped > 0 {
+		fmt.Printf("WARNING: %d values weren't learned because of the memory limit (%d were)\n", dropped, stored)
+	}
+
 	fmt.Printf("\n\nPlaying greedily...\n")
 	printTraces(*nTraces, sar)
 	fightRandom(*nTest, sar)
 
+	if *opponentPath != "" {
+		opponent := td.NewTDWithMemory(enc, 0, 0, *memory<<20)
+		if err := opponent.LoadFromFile(*opponentPath); err != nil {
+			panic(err)
+		}
+		first, _, _ := fightPlayers(*nTest, []players.Player{sar, opponent})
+		second, _, _ := fightPlayers(*nTest, []players.Player{opponent, sar})
+		fmt.Printf("Win rates vs '%s': %2.1f%%, %2.1f%%\n", *opponentPath, first, 100.0-second)
+	}
+
 	if *savePath != "" {
 		err := sar.SaveToFile(*savePath)
 		if err != nil {
@@ -87,14 +110,21 @@ func main() {
 func printTraces(n int, sar *td.TD) {
 	fists := make([]rules.FinalState, 0, n)
 	for i := 0; i < n; i++ {
-		tr, err := gamemaster.TraceOneGame(&players.RandomPlayer{})
+		tr, err := gamemaster.TraceOneGameEncoded(&players.RandomPlayer{}, 2, sar.Encoder())
 		if err != nil {
 			panic(err.Error())
 		}
 		fmt.Printf("Game %d winner: %d\n", i, tr.Winner)
 		for plID, v := range tr.StateInfos {
-			seenCards, recent, old, opponent, scoreDelta := state.FromIndex(state.IndexWithoutAction(v.ActionState))
-			action := rules.ActionFromInt(state.ActionFromIndex(v.ActionState))
+			size := sar.Encoder().Size()
+			simple, ok := simpleIndex(sar.Encoder(), v.ActionState%size)
+			if !ok {
+				// Only simple states can be decoded
+				fmt.Printf("    %d: %08X: %0.3f\n", plID%2, v.ActionState, sar.Value(v.ActionState))
+				continue
+			}
+			seenCards, recent, old, opponent, scoreDelta := state.FromIndex(simple)
+			action := rules.ActionFromInt(v.ActionState / size)
 			fmt.Printf("    %d: %08X: %0.3f (1:%v, 2:%v, o:%v, del:%d, deck:%v)(1:%v, o:%d, g:%v)\n", plID%2, v.ActionState, sar.Value(v.ActionState), recent, old, opponent, scoreDelta, seenCards, action.PlayRecent, action.TargetPlayerOffset, action.SelectedCard)
 		}
 		fists = append(fists, tr.FinalState)
@@ -106,18 +136,28 @@ func printTraces(n int, sar *td.TD) {
 	}
 }
 
+// simpleIndex returns the Simple state index for the encoder's state, if it has one.
+func simpleIndex(enc state.StateEncoder, st int) (int, bool) {
+	switch enc := enc.(type) {
+	case state.SimpleEncoder:
+		return st, true
+	case *state.CompactEncoder:
+		return enc.SimpleIndex(st)
+	case state.CanonicalEncoder:
+		return simpleIndex(enc.Encoder, st)
+	}
+	return 0, false
+}
+
 func fightRandom(n int, pl players.Player) {
-	fmt.Printf("Sarsa win rates: %2.1f%%,", fightPlayers(n, []players.Player{
-		pl,
-		&players.RandomPlayer{},
-	}))
-	fmt.Printf(" %2.1f%%\n", 100.0-fightPlayers(n, []players.Player{
-		&players.RandomPlayer{},
-		pl,
-	}))
+	first, illegalFirst, playsFirst := fightPlayers(n, []players.Player{pl, &players.RandomPlayer{}})
+	second, illegalSecond, playsSecond := fightPlayers(n, []players.Player{&players.RandomPlayer{}, pl})
+	fmt.Printf("Sarsa win rates: %2.1f%%, %2.1f%% (%d of %d greedy picks were illegal)\n", first, 100.0-second,
+		illegalFirst[0]+illegalSecond[1], playsFirst[0]+playsSecond[1])
 }
 
-func fightPlayers(n int, pls []players.Player) float32 {
+// fightPlayers returns player 0's win rate, and the number of illegal plays and plays by each player.
+func fightPlayers(n int, pls []players.Player) (float32, []int, []int) {
 	// Now fight vs Random
 	gm, err := gamemaster.New(pls)
 	if err != nil {
@@ -128,5 +168,5 @@ func fightPlayers(n int, pls []players.Player) float32 {
 		panic(err)
 	}
 
-	return float32(wins) / float32(n) * 100.0
+	return float32(wins) / float32(n) * 100.0, gm.IllegalPlays, gm.Plays
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	crand "crypto/rand"
 	"errors"
 	"flag"
 	"fmt"
@@ -28,11 +29,19 @@ const NUMBER_OF_PLAYERS = 2
 var (
 	sarsaFile = flag.String("sarsa", "", "Path to a sarsa file")
 	qFile     = flag.String("q", "", "Path to a Q learning file")
+	memory    = flag.Int("memory", 0, "Most megabytes used by the bot's values, or 0 for no limit (values that don't fit are stored sparsely)")
 
 	config = struct {
 		Resources string `default:"../../res"`
 		Address   string `default:":8080"`
+
+		// TokenSecret is the secret used to seal game tokens. If it's empty, a random one is used, so games are lost
+		// when the server restarts.
+		TokenSecret string        `split_words:"true"`
+		TokenTTL    time.Duration `split_words:"true" default:"24h"`
 	}{}
+
+	sealer *rules.Sealer
 )
 
 func exitIfError(err error, reason string) {
@@ -58,18 +67,29 @@ func main() {
 
 	exitIfError(envconfig.Process("LLAI", &config), "failed to parse environment")
 
+	secret := []byte(config.TokenSecret)
+	if len(secret) == 0 {
+		log.Println("LLAI_TOKEN_SECRET is not set, so a random secret is used")
+		secret = make([]byte, 32)
+		_, err := crand.Read(secret)
+		exitIfError(err, "generating a token secret")
+	}
+	var err error
+	sealer, err = rules.NewSealer(secret, config.TokenTTL)
+	exitIfError(err, "creating the token sealer")
+
 	bots := map[string]players.Player{
 		"random": &players.RandomPlayer{},
 	}
 
 	if *sarsaFile != "" {
-		sarsa := td.NewTD(0, 0)
+		sarsa := td.NewTDWithMemory(state.SimpleEncoder{}, 0, 0, *memory<<20)
 		exitIfError(sarsa.LoadFromFile(*sarsaFile), "loading sarsa file")
 		bots["sarsa"] = sarsa.SarsaLearner()
 	}
 
 	if *qFile != "" {
-		q := montecarlo.NewQPlayer(0)
+		q := montecarlo.NewQPlayerWithMemory(state.SimpleEncoder{}, 0, *memory<<20)
 		exitIfError(q.LoadFromFile(*qFile), "loading Q file")
 		bots["q"] = q
 	}
@@ -77,6 +97,16 @@ func main() {
 	rand.Seed(time.Now().UnixNano())
 
 	score := []int{0, 0} // Number of wins for each player
+	ties := 0            // Number of games where both players shared the win
+
+	// recordResult updates the score for a game that ended
+	recordResult := func(game rules.Gamestate) {
+		if len(game.Winners) > 1 {
+			ties++
+		} else {
+			score[game.Winner]++
+		}
+	}
 
 	http.Handle("/static/", http.StripPrefix("/static", http.FileServer(http.Dir(resourcePath("static")))))
 
@@ -84,6 +114,7 @@ func main() {
 		game := gameFromToken(cookieID(r))
 
 		botName := "random"
+		notices := []string{}
 
 		switch r.Method {
 		case "POST":
@@ -107,11 +138,16 @@ func main() {
 				act.TargetPlayerOffset = 1
 			}
 			act.SelectedCard = rules.CardFromString(r.FormValue("guess"))
-			game.PlayCard(act, rand)
+			if err := game.TryPlayCard(act, rand); err != nil {
+				// Reject the play and let the human try again
+				log.Printf("Rejected action: %v", err)
+				notices = append(notices, "You can't do that: "+err.Error())
+				break
+			}
 
 			// Did the player's move end the game?
 			if game.GameEnded {
-				score[game.Winner]++
+				recordResult(game)
 				game.Reset(rand)
 				break
 			}
@@ -129,7 +165,7 @@ func main() {
 			game.PlayCard(action, rand)
 
 			if game.GameEnded {
-				score[game.Winner]++
+				recordResult(game)
 				game.Reset(rand)
 			}
 
@@ -137,13 +173,15 @@ func main() {
 		}
 
 		gd := GameData{
-			Game: stateForTemplate(game),
+			Game: stateForTemplate(game, notices),
 			Score: struct {
 				You      int
 				Computer int
+				Ties     int
 			}{
 				You:      score[0],
 				Computer: score[1],
+				Ties:     ties,
 			},
 			Opponents: Opponents(botName, bots),
 		}
@@ -190,8 +228,11 @@ func gameFromToken(tok string) rules.Gamestate {
 	err := errors.New("")
 
 	if tok != "" {
-		// Try loading if there's a token
-		err = game.FromToken(tok)
+		// Try loading if there's a token. Old, expired or modified tokens start a new game.
+		game, err = sealer.Open(tok)
+		if err != nil {
+			log.Printf("Rejected token: %v", err)
+		}
 	}
 
 	if err != nil {
@@ -205,7 +246,20 @@ func gameFromToken(tok string) rules.Gamestate {
 	return game
 }
 
-func stateForTemplate(game rules.Gamestate) interface{} {
+// eventLines returns the events the human is allowed to see, newest first, after any notices.
+func eventLines(game rules.Gamestate, notices []string) []string {
+	lines := game.EventLog.VisibleTo(0).Lines()
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	lines = append(notices, lines...)
+	for i := range lines {
+		lines[i] = template.HTMLEscapeString(lines[i])
+	}
+	return lines
+}
+
+func stateForTemplate(game rules.Gamestate, notices []string) interface{} {
 	type PlayedCards struct {
 		You      []string
 		Computer []string
@@ -227,11 +281,15 @@ func stateForTemplate(game rules.Gamestate) interface{} {
 			You:      game.Discards[0].Strings(),
 			Computer: game.Discards[1].Strings(),
 		},
-		LastPlay:    game.LastPlay[1].String(),
-		Card1:       game.CardInHand[0].String(),
-		Card2:       game.ActivePlayerCard.String(), // TODO this assumes that the current player is the active player
-		EventLog:    template.HTML(strings.Join(game.EventLog.Events, "<br>")),
-		GameStateID: game.Token(),
+		LastPlay: game.LastPlay[1].String(),
+		Card1:    game.CardInHand[0].String(),
+		Card2:    game.ActivePlayerCard.String(), // TODO this assumes that the current player is the active player
+		EventLog: template.HTML(strings.Join(eventLines(game, notices), "<br>")),
+	}
+	token, err := sealer.Seal(&game)
+	if err != nil {
+		log.Printf("Failed to seal the game: %v", err)
 	}
+	data.GameStateID = token
 	return data
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"love-letter-ai/players"
+	"love-letter-ai/state"
+	"love-letter-ai/tablebase"
+	"love-letter-ai/td"
+)
+
+var loadPath = flag.String("load", "", "Path to the file to load the tablebase")
+var savePath = flag.String("save", "", "Path to the file to save the tablebase")
+var sarsaPath = flag.String("sarsa", "", "Path to the file with Sarsa weights to compare with the tablebase")
+var maxDrawPile = flag.Int("draws", 3, "Largest number of cards left to draw in the positions solved")
+var nGames = flag.Int("games", 10000, "Number of games used to measure how often a player chooses the best action")
+var memory = flag.Int("memory", 0, "Most megabytes used by each table of values, or 0 for no limit (tables that don't fit are stored sparsely)")
+
+func main() {
+	flag.Parse()
+	r := rand.New(rand.NewSource(4492))
+
+	tb := tablebase.New(*maxDrawPile)
+	if *loadPath != "" {
+		if err := tb.LoadFromFile(*loadPath); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Loaded %d positions with up to %d cards to draw from '%s'\n", tb.Len(), tb.MaxDrawPile, *loadPath)
+	}
+
+	start := time.Now()
+	compare("Random", tb, &players.RandomPlayer{}, r)
+	fmt.Printf("The tablebase has %d positions (%v)\n", tb.Len(), time.Since(start))
+
+	if *sarsaPath != "" {
+		sar := td.NewTDWithMemory(state.SimpleEncoder{}, 0, 1, *memory<<20)
+		if err := sar.LoadFromFile(*sarsaPath); err != nil {
+			panic(err)
+		}
+		compare("Sarsa", tb, sar, r)
+	}
+
+	if *savePath != "" {
+		if err := tb.SaveToFile(*savePath); err != nil {
+			panic(err)
+		}
+		fmt.Println("The tablebase was saved at '" + *savePath + "'")
+	}
+}
+
+func compare(name string, tb *tablebase.Tablebase, pl players.Player, r *rand.Rand) {
+	optimal, total, err := tablebase.Agreement(tb, pl, *nGames, r)
+	if err != nil {
+		panic(err)
+	}
+	if total == 0 {
+		fmt.Printf("%s never reached a position in the tablebase\n", name)
+		return
+	}
+	fmt.Printf("%s played the best action by the perfect-information average in %d of %d positions (%2.1f%%)\n", name, optimal, total, float32(optimal)/float32(total)*100)
+}
@@ -15,9 +15,12 @@ type Gamemaster struct {
 	// Gamestate tracks the state of the game
 	rules.Gamestate
 
-	// Wins tracks each player's wins so far
+	// Wins tracks each player's wins so far. When players share a win, each of them gets the win.
 	Wins []int
 
+	// Plays and IllegalPlays count each player's actions so far, and the actions that broke the rules.
+	Plays, IllegalPlays []int
+
 	// startPlayerOffset is the id of the player who started the current game
 	startPlayerOffset int
 
@@ -36,7 +39,21 @@ func New(players []players.Player) (Gamemaster, error) {
 }
 
 func (master *Gamemaster) TakeTurn() {
-	action := master.Players[master.ActivePlayer].PlayCard(state.NewSimple(master.Gamestate))
+	var action rules.Action
+	if pl, ok := master.Players[master.ActivePlayer].(players.ViewPlayer); ok {
+		action = pl.PlayView(master.ViewFor(master.ActivePlayer))
+	} else {
+		action = master.Players[master.ActivePlayer].PlayCard(state.NewSimple(master.Gamestate))
+	}
+
+	if master.Plays == nil {
+		master.Plays = make([]int, len(master.Players))
+		master.IllegalPlays = make([]int, len(master.Players))
+	}
+	master.Plays[master.ActivePlayer]++
+	if master.CheckAction(action) != nil {
+		master.IllegalPlays[master.ActivePlayer]++
+	}
 	master.PlayCard(action, master.rand)
 }
 
@@ -44,7 +61,9 @@ func (master *Gamemaster) PlayGame() {
 	for !master.GameEnded {
 		master.TakeTurn()
 	}
-	master.Wins[master.Winner] += 1
+	for _, winner := range master.Winners {
+		master.Wins[winner] += 1
+	}
 }
 
 // PlaySeries plays an entire series with the provided players, returning the id of the player who won.
@@ -57,13 +76,16 @@ func (master *Gamemaster) PlaySeries(gamesToWin int) (int, error) {
 		}
 
 		master.PlayGame()
-		winner := (master.Winner - master.startPlayerOffset + master.NumPlayers) % master.NumPlayers
-		master.startPlayerOffset = winner
+		// The winner starts the next game. If the win was shared, one of the winners is chosen at random.
+		master.startPlayerOffset = master.Winners[master.rand.Intn(len(master.Winners))]
 		var err error
 		master.Gamestate, err = rules.NewGame(master.NumPlayers, master.rand)
 		if err != nil {
 			return 0, err
 		}
+		if err := master.StartWith(master.startPlayerOffset); err != nil {
+			return 0, err
+		}
 	}
 }
 
@@ -81,7 +103,7 @@ func (master *Gamemaster) PlayStatistics(totalGames int) (int, error) {
 }
 
 // HighScore returns the player who scored highest and that player's score.
-// It also returns a bool to indicate if there's a tie. There isn't currently any way to tie.
+// It also returns a bool to indicate if more than one player has the highest score.
 func (master *Gamemaster) HighScore() (int, int, bool) {
 	maxPid := -1
 	maxScore := 0
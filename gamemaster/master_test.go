@@ -0,0 +1,62 @@
+package gamemaster
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"love-letter-ai/players"
+	"love-letter-ai/rules"
+	"love-letter-ai/state"
+)
+
+// maskedPlayer plays a random action from the legal mask.
+type maskedPlayer struct{}
+
+func (maskedPlayer) PlayCard(st state.Simple) rules.Action {
+	return st.TargetOpponent(rules.ActionFromInt(st.LegalMask().Random(nil)))
+}
+
+func (pl maskedPlayer) PlayCardRand(st state.Simple, r *rand.Rand) rules.Action {
+	return pl.PlayCard(st)
+}
+
+// viewMaskedPlayer plays a random action from the view's legal mask.
+type viewMaskedPlayer struct{ maskedPlayer }
+
+func (viewMaskedPlayer) PlayView(view rules.PlayerView) rules.Action {
+	return state.TargetOpponent(view, rules.ActionFromInt(state.ViewMask(view).Random(nil)))
+}
+
+// selfTargetingPlayer always targets itself, which is illegal for most cards.
+type selfTargetingPlayer struct{}
+
+func (selfTargetingPlayer) PlayCard(st state.Simple) rules.Action {
+	return rules.Action{PlayRecent: st.RecentDraw != rules.Princess, SelectedCard: rules.Priest}
+}
+
+func (pl selfTargetingPlayer) PlayCardRand(st state.Simple, r *rand.Rand) rules.Action {
+	return pl.PlayCard(st)
+}
+
+func TestIllegalPlaysAreCounted(t *testing.T) {
+	gm, err := New([]players.Player{maskedPlayer{}, selfTargetingPlayer{}})
+	assert.NoError(t, err)
+	_, err = gm.PlayStatistics(200)
+	assert.NoError(t, err)
+
+	assert.NotZero(t, gm.Plays[0])
+	// Masked actions are legal in 2-player games, other than a Prince targeting a protected opponent
+	assert.True(t, gm.IllegalPlays[0] < gm.Plays[0]/20, "%d of %d plays were illegal", gm.IllegalPlays[0], gm.Plays[0])
+	assert.NotZero(t, gm.IllegalPlays[1])
+	assert.True(t, gm.IllegalPlays[1] <= gm.Plays[1])
+
+	// Masks from the view only contain the game's legal actions
+	gm, err = New([]players.Player{viewMaskedPlayer{}, viewMaskedPlayer{}})
+	assert.NoError(t, err)
+	_, err = gm.PlayStatistics(200)
+	assert.NoError(t, err)
+	assert.NotZero(t, gm.Plays[0])
+	assert.Equal(t, []int{0, 0}, gm.IllegalPlays)
+}
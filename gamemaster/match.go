@@ -0,0 +1,140 @@
+package gamemaster
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"love-letter-ai/players"
+	"love-letter-ai/rules"
+)
+
+// Match is a series of rounds played with the official rules: each winner of a round gets a token of affection (plus
+// any bonus tokens from the round), a winner of each round starts the next one, and the first player to collect
+// enough tokens wins the match.
+type Match struct {
+	Players []players.Player
+
+	// Cards is the CardSet used for every round.
+	Cards *rules.CardSet
+
+	// TokensToWin is the number of tokens needed to win the match.
+	TokensToWin int
+
+	// Tokens contains the tokens each player has collected so far.
+	Tokens []int
+
+	// Rounds contains every round played so far, in order.
+	Rounds []Round
+
+	// NextPlayer is the id of the player who starts the next round.
+	NextPlayer int
+
+	rand *rand.Rand
+}
+
+// Round is the result of one round of a Match.
+type Round struct {
+	// FirstPlayer is the id of the player who started the round.
+	FirstPlayer int
+
+	// Winners contains every player who won the round. There's more than one if the win was shared.
+	Winners []int
+
+	// Tokens contains the tokens each player earned in the round, including bonus tokens.
+	Tokens []int
+
+	// Record can replay the round with rules.Replay.
+	Record rules.GameRecord
+}
+
+// NewMatch returns a match between the players, who need the official number of tokens for the CardSet to win.
+// Player 0 starts the first round.
+func NewMatch(pls []players.Player, cards *rules.CardSet, r *rand.Rand) (*Match, error) {
+	if len(pls) < cards.MinPlayers || len(pls) > cards.MaxPlayers {
+		return nil, fmt.Errorf("Only matches with %d to %d players are supported by the %s cards", cards.MinPlayers, cards.MaxPlayers, cards.Name)
+	}
+	return &Match{
+		Players:     pls,
+		Cards:       cards,
+		TokensToWin: cards.TokensNeeded(len(pls)),
+		Tokens:      make([]int, len(pls)),
+		rand:        r,
+	}, nil
+}
+
+// PlayRound plays the next round of the match, and returns its result.
+func (match *Match) PlayRound() (Round, error) {
+	if _, over := match.Winner(); over {
+		return Round{}, errors.New("The match is already over")
+	}
+
+	state, err := rules.NewGameWithCards(match.Cards, len(match.Players), match.rand)
+	if err != nil {
+		return Round{}, err
+	}
+	if err := state.StartWith(match.NextPlayer); err != nil {
+		return Round{}, err
+	}
+	record, err := state.StartRecording()
+	if err != nil {
+		return Round{}, err
+	}
+	master := Gamemaster{
+		Players:   match.Players,
+		Gamestate: state,
+		Wins:      make([]int, len(match.Players)),
+		rand:      match.rand,
+	}
+	master.PlayGame()
+
+	round := Round{
+		FirstPlayer: match.NextPlayer,
+		Winners:     append([]int(nil), master.Winners...),
+		Tokens:      make([]int, len(match.Players)),
+		Record:      *record,
+	}
+	for _, winner := range master.Winners {
+		round.Tokens[winner]++
+	}
+	for pid, bonus := range master.BonusTokens {
+		round.Tokens[pid] += bonus
+	}
+	for pid, tokens := range round.Tokens {
+		match.Tokens[pid] += tokens
+	}
+	match.Rounds = append(match.Rounds, round)
+
+	// If the win was shared, one of the winners is chosen at random to start
+	match.NextPlayer = master.Winners[match.rand.Intn(len(master.Winners))]
+	return round, nil
+}
+
+// Winner returns the id of the player who won the match, and true if the match is over. The match is over once a
+// player has at least TokensToWin tokens, and more than anyone else. If players tie, more rounds are played.
+func (match *Match) Winner() (int, bool) {
+	best, tie := 0, false
+	for pid, tokens := range match.Tokens {
+		if tokens > match.Tokens[best] {
+			best, tie = pid, false
+		} else if pid != best && tokens == match.Tokens[best] {
+			tie = true
+		}
+	}
+	if tie || match.Tokens[best] < match.TokensToWin {
+		return -1, false
+	}
+	return best, true
+}
+
+// Play plays rounds until the match is over, and returns the id of the winner.
+func (match *Match) Play() (int, error) {
+	for {
+		if winner, over := match.Winner(); over {
+			return winner, nil
+		}
+		if _, err := match.PlayRound(); err != nil {
+			return -1, err
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package gamemaster
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"love-letter-ai/players"
+	"love-letter-ai/rules"
+)
+
+func TestMatchPlaysUntilATokenTarget(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, cards := range []*rules.CardSet{rules.ClassicCards, rules.Edition2019Cards, rules.PremiumCards} {
+		pls := make([]players.Player, cards.MinPlayers)
+		for pid := range pls {
+			pls[pid] = &players.RandomPlayer{}
+		}
+		match, err := NewMatch(pls, cards, r)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, cards.TokensNeeded(len(pls)), match.TokensToWin)
+
+		winner, err := match.Play()
+		assert.NoError(t, err)
+		assert.True(t, match.Tokens[winner] >= match.TokensToWin)
+		for pid, tokens := range match.Tokens {
+			if pid != winner {
+				assert.True(t, tokens < match.Tokens[winner])
+			}
+		}
+
+		total := make([]int, len(pls))
+		for i, round := range match.Rounds {
+			if i > 0 {
+				assert.Contains(t, match.Rounds[i-1].Winners, round.FirstPlayer, "A winner starts the next round")
+			}
+			assert.Equal(t, round.FirstPlayer, round.Record.ActivePlayer)
+			for pid, tokens := range round.Tokens {
+				total[pid] += tokens
+			}
+			states, err := rules.Replay(round.Record)
+			assert.NoError(t, err)
+			assert.Equal(t, round.Winners, states[len(states)-1].Winners)
+		}
+		assert.Equal(t, total, match.Tokens)
+
+		_, err = match.PlayRound()
+		assert.Error(t, err, "The match is over")
+	}
+}
+
+func TestMatchWinner(t *testing.T) {
+	match := Match{TokensToWin: 4, Tokens: []int{3, 4, 2}}
+	winner, over := match.Winner()
+	assert.True(t, over)
+	assert.Equal(t, 1, winner)
+
+	match.Tokens = []int{4, 4, 2}
+	_, over = match.Winner()
+	assert.False(t, over, "Tied players keep playing")
+
+	match.Tokens = []int{3, 1, 2}
+	_, over = match.Winner()
+	assert.False(t, over)
+}
+
+func TestNewMatchRejectsPlayerCounts(t *testing.T) {
+	_, err := NewMatch([]players.Player{&players.RandomPlayer{}}, rules.ClassicCards, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}
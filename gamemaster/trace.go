@@ -12,19 +12,46 @@ import (
 type StateInfo struct {
 	State       int
 	ActionState int
-	Won         bool
+
+	// Won is true if the player won the game, including when they shared the win.
+	Won bool
+
+	// Player is the id of the player who was active in this state.
+	Player int
 }
 
 type Trace struct {
 	StateInfos []StateInfo
 	FinalState rules.FinalState
 	Winner     int
+
+	// Winners contains every player who won. There's more than one if the win was shared.
+	Winners []int
+
+	// Record can replay the game with rules.Replay.
+	Record rules.GameRecord
 }
 
-// TraceOneGame returns the states for one gameplay played by the provided player pl.
+// TraceOneGame returns the states for one 2-player gameplay played by the provided player pl.
 func TraceOneGame(pl players.Player) (Trace, error) {
+	return TraceOneGameForPlayers(pl, 2)
+}
+
+// TraceOneGameForPlayers returns the states for one gameplay with numPlayers players, all played by the provided player pl.
+func TraceOneGameForPlayers(pl players.Player, numPlayers int) (Trace, error) {
+	return TraceOneGameEncoded(pl, numPlayers, state.SimpleEncoder{})
+}
+
+// TraceOneGameEncoded returns the states for one gameplay with numPlayers players, all played by the provided player
+// pl, using the encoder for the states. If pl is a players.ViewPlayer, it plays from each player's view.
+func TraceOneGameEncoded(pl players.Player, numPlayers int, enc state.StateEncoder) (Trace, error) {
 	r := rand.New(rand.NewSource(rand.Int63()))
-	sg, err := rules.NewGame(2, r)
+	sg, err := rules.NewGame(numPlayers, r)
+	if err != nil {
+		return Trace{}, err
+	}
+
+	record, err := sg.StartRecording()
 	if err != nil {
 		return Trace{}, err
 	}
@@ -32,29 +59,31 @@ func TraceOneGame(pl players.Player) (Trace, error) {
 	tr := Trace{StateInfos: make([]StateInfo, 0, 15)}
 
 	for !sg.GameEnded {
-		s := state.NewSimple(sg)
-		if s.OpponentCard == 0 {
-			s.OpponentCard++
+		view := sg.ViewFor(sg.ActivePlayer)
+		var action rules.Action
+		if vp, ok := pl.(players.ViewPlayer); ok {
+			action = vp.PlayView(view)
+		} else {
+			action = pl.PlayCard(state.SimpleFromView(view))
 		}
-
-		action := pl.PlayCard(s)
-		sa, ss := s.AsIndexWithAction(action)
+		sa, ss := state.EncodeAction(enc, view, action)
 		if ss < 0 || sa < 0 {
 			return Trace{}, fmt.Errorf("Negative state was calculated: %d %d", ss, sa)
 		}
-		tr.StateInfos = append(tr.StateInfos, StateInfo{State: ss, ActionState: sa})
+		tr.StateInfos = append(tr.StateInfos, StateInfo{State: ss, ActionState: sa, Player: sg.ActivePlayer})
 		sg.PlayCard(action, r)
 	}
 
-	numPlays := len(tr.StateInfos)
-	for i := numPlays - 1; i >= 0; i-- {
-		// Leave ret[i] as zero unless this was the winner
-		if i%2 == sg.Winner {
+	for i := range tr.StateInfos {
+		// Leave ret[i] as zero unless this was a winner
+		if sg.IsWinner(tr.StateInfos[i].Player) {
 			tr.StateInfos[i].Won = true
 		}
 	}
 	tr.Winner = sg.Winner
+	tr.Winners = sg.Winners
 	tr.FinalState = sg.FinalState
+	tr.Record = *record
 
 	return tr, nil
 }
@@ -3,9 +3,12 @@ package montecarlo
 import (
 	"bufio"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"love-letter-ai/gamemaster"
 	"love-letter-ai/players"
+	"love-letter-ai/qtable"
 	"love-letter-ai/rules"
 	"love-letter-ai/state"
 	"math/rand"
@@ -13,17 +16,36 @@ import (
 )
 
 type QPlayer struct {
-	qf      []Value
+	qf      qtable.Table // Each Value, packed by pack
+	encoder state.StateEncoder
 	epsilon float32
 }
 
+// NewQPlayer returns a QPlayer that learns the value of each state-action from state.Simple.
 func NewQPlayer(epsilon float32) *QPlayer {
+	return NewQPlayerWithEncoder(state.SimpleEncoder{}, epsilon)
+}
+
+// NewQPlayerWithEncoder returns a QPlayer that learns the value of each state-action from the encoder's states.
+func NewQPlayerWithEncoder(enc state.StateEncoder, epsilon float32) *QPlayer {
+	return NewQPlayerWithMemory(enc, epsilon, 0)
+}
+
+// NewQPlayerWithMemory returns a QPlayer like NewQPlayerWithEncoder whose values use at most about maxBytes (or 0 for
+// no limit). If every value doesn't fit, only the state-actions that are seen are stored, until the limit is reached.
+func NewQPlayerWithMemory(enc state.StateEncoder, epsilon float32, maxBytes int) *QPlayer {
 	return &QPlayer{
-		qf:      make([]Value, state.ActionSpaceMagnitude, state.ActionSpaceMagnitude),
+		qf:      qtable.New(state.ActionSize(enc), 0, maxBytes),
+		encoder: enc,
 		epsilon: epsilon,
 	}
 }
 
+// Encoder returns the encoder for the states whose values are learned.
+func (qp *QPlayer) Encoder() state.StateEncoder {
+	return qp.encoder
+}
+
 func (qp *QPlayer) SetEpsilon(epsilon float32) {
 	qp.epsilon = epsilon
 }
@@ -34,7 +56,7 @@ func (qp *QPlayer) TrainWithPlayerPolicy(episodes int, pl players.Player) {
 			fmt.Printf("\r%2.2f%% complete", float32(i)/float32(episodes)*100)
 		}
 
-		tr, err := gamemaster.TraceOneGame(pl)
+		tr, err := gamemaster.TraceOneGameEncoded(pl, 2, qp.encoder)
 		if err != nil {
 			panic(err.Error())
 		}
@@ -51,43 +73,79 @@ func (qp *QPlayer) TrainWithSelfPolicy(episodes int) {
 }
 
 // PlayCard provides a suggested action for the provided state.
-// If it hasn't learned anything for this state, it plays randomly.
-// It will also choose a random action with probability epsilon. This isn't exactly
+// If it hasn't learned anything for this state, it plays a random legal action.
+// It will also choose a random legal action with probability epsilon. This isn't exactly
 // epsilon-greedy because it doesn't subtract the probability of the greedy action.
+// A state.Simple can only be encoded by the state.SimpleEncoder, so with any other encoder it always plays randomly;
+// use PlayView instead.
 func (qp *QPlayer) PlayCard(state state.Simple) rules.Action {
-	act := qp.policy(state.AsIndex())
+	return qp.PlayCardRand(state, nil)
+}
+
+// PlayCardRand is the same as PlayCard, using r for random plays (or the default source if it's nil).
+func (qp *QPlayer) PlayCardRand(state state.Simple, r *rand.Rand) rules.Action {
+	mask := state.LegalMask()
+	act := qp.simplePolicy(state, mask)
+	if act == nil || randFloat32(r) < qp.epsilon {
+		return state.TargetOpponent(rules.ActionFromInt(mask.Random(r)))
+	}
+	return state.TargetOpponent(*act)
+}
+
+// PlayView provides a suggested action for the active player's view, using the encoder, in the same way as PlayCard.
+func (qp *QPlayer) PlayView(view rules.PlayerView) rules.Action {
+	mask := state.EncodedMask(qp.encoder, view)
+	act := qp.policy(qp.encoder.Index(view), mask)
 	if act == nil || rand.Float32() < qp.epsilon {
-		return (&players.RandomPlayer{}).PlayCard(state)
+		return state.DecodeAction(qp.encoder, view, rules.ActionFromInt(mask.Random(nil)))
 	}
-	return *act
+	return state.DecodeAction(qp.encoder, view, *act)
 }
 
-func (qp *QPlayer) PlayCardRand(state state.Simple, r *rand.Rand) rules.Action {
-	act := qp.policy(state.AsIndex())
-	if act == nil || r.Float32() < qp.epsilon {
-		return (&players.RandomPlayer{}).PlayCardRand(state, r)
+// simplePolicy returns the greedy action for the state, or nil if it can't be encoded or nothing has been learned.
+func (qp *QPlayer) simplePolicy(st state.Simple, mask state.ActionMask) *rules.Action {
+	if _, ok := qp.encoder.(state.SimpleEncoder); !ok {
+		return nil
 	}
-	return *act
+	return qp.policy(st.AsIndex(), mask)
+}
+
+// randFloat32 returns a random number from r, or the default source if it's nil.
+func randFloat32(r *rand.Rand) float32 {
+	if r == nil {
+		return rand.Float32()
+	}
+	return r.Float32()
+}
+
+// Stored returns the number of values stored, and the number that were dropped because of the memory limit.
+func (qp QPlayer) Stored() (int, int) {
+	return qp.qf.Stored(), qp.qf.Dropped()
 }
 
 func (qp QPlayer) Value(st int) float32 {
-	cnt := float32(qp.qf[st].count)
+	v := unpack(qp.qf.Get(st))
+	cnt := float32(v.count)
 	if cnt == 0.0 {
 		return 0.0
 	}
-	sum := float32(qp.qf[st].sum)
+	sum := float32(v.sum)
 	if sum == 0.0 {
 		return 0.0
 	}
 	return sum / cnt
 }
 
-// policy returns the greedy action for the given state. (Note the argument should be a state, not an action-state.)
+// policy returns the greedy action for the given state, out of the actions in the mask. (Note the argument should be
+// a state, not an action-state.)
 // Ties are broken by choosing the first option (i.e. arbitrarily in a deterministic way).
-func (qp QPlayer) policy(st int) *rules.Action {
+func (qp QPlayer) policy(st int, mask state.ActionMask) *rules.Action {
 	bestActs := []int{}
 	bestActValue := float32(0)
-	for act, actState := range state.AllActionStates(st) {
+	for act, actState := range state.ActionStates(qp.encoder, st) {
+		if !mask.Has(act) {
+			continue
+		}
 		thisVal := qp.Value(actState)
 		if thisVal > bestActValue {
 			bestActValue = thisVal
@@ -106,16 +164,18 @@ func (qp QPlayer) policy(st int) *rules.Action {
 
 func (qp *QPlayer) SaveState(si gamemaster.StateInfo) {
 	s := si.ActionState
+	v := unpack(qp.qf.Get(s))
 	if si.Won {
-		qp.qf[s].sum++
+		v.sum++
 	}
-	qp.qf[s].count++
+	v.count++
 
 	// Check for overflow
-	if qp.qf[s].count == 0xFFFF {
-		qp.qf[s].sum /= 2
-		qp.qf[s].count /= 2
+	if v.count == 0xFFFF {
+		v.sum /= 2
+		v.count /= 2
 	}
+	qp.qf.Set(s, v.pack())
 }
 
 type fileHeader struct {
@@ -124,6 +184,12 @@ type fileHeader struct {
 	ActionSpaceMagnitude uint64
 }
 
+const (
+	denseFileFormatVersion  = 1 // Every value, in order
+	sparseFileFormatVersion = 2 // The number of values stored, then the index and value of each one
+)
+
+// SaveToFile saves the values. Dense tables save every value, and sparse tables only save the values stored.
 func (qp QPlayer) SaveToFile(path string) error {
 	file, err := os.Create(path)
 	defer file.Close()
@@ -131,38 +197,52 @@ func (qp QPlayer) SaveToFile(path string) error {
 		return err
 	}
 
-	length := len(qp.qf)
+	length := qp.qf.Len()
+	stored := qp.qf.Stored()
+	version := sparseFileFormatVersion
+	if _, ok := qp.qf.(qtable.Dense); ok {
+		version = denseFileFormatVersion
+	}
 	writer := bufio.NewWriter(file)
 
 	err = binary.Write(writer, binary.BigEndian, fileHeader{
-		Version:              1,
+		Version:              uint32(version),
 		Epsilon:              qp.epsilon,
 		ActionSpaceMagnitude: uint64(length),
 	})
+	if err == nil && version == sparseFileFormatVersion {
+		err = binary.Write(writer, binary.BigEndian, uint64(stored))
+	}
 	if err != nil {
 		return err
 	}
 
-	by := make([]byte, 4)
-	for i, val := range qp.qf {
-		if i%(length/100) == 0 {
-			fmt.Printf("\rSaving %2d%%", i*100/length)
+	n := 0
+	qp.qf.Each(func(i int, val uint32) {
+		if err != nil {
+			return
 		}
-
-		by[0] = byte(val.sum & 0xFF)
-		by[1] = byte(val.sum >> 8)
-		by[2] = byte(val.count & 0xFF)
-		by[3] = byte(val.count >> 8)
-
-		if _, err := writer.Write(by); err != nil {
-			return err
+		if stored >= 100 && n%(stored/100) == 0 {
+			fmt.Printf("\rSaving %2d%%", n*100/stored)
 		}
+		n++
+		if version == sparseFileFormatVersion {
+			if err = binary.Write(writer, binary.BigEndian, uint32(i)); err != nil {
+				return
+			}
+		}
+		// The packed value is the sum then the count, each in little-endian order
+		err = binary.Write(writer, binary.LittleEndian, val)
+	})
+	if err != nil {
+		return err
 	}
 	fmt.Printf("\rSaved 100%%\n")
 
 	return writer.Flush()
 }
 
+// LoadFromFile loads values saved by SaveToFile into the table, whether it's dense or sparse.
 func (qp *QPlayer) LoadFromFile(path string) error {
 	file, err := os.Open(path)
 	defer file.Close()
@@ -170,7 +250,7 @@ func (qp *QPlayer) LoadFromFile(path string) error {
 		return err
 	}
 
-	length := len(qp.qf)
+	length := qp.qf.Len()
 
 	reader := bufio.NewReader(file)
 	header := &fileHeader{}
@@ -178,27 +258,48 @@ func (qp *QPlayer) LoadFromFile(path string) error {
 	if err != nil {
 		return err
 	}
-	if header.Version != 1 {
-		return fmt.Errorf("Cannot load MC weights from version not 1 (%d)", header.Version)
+	if header.Version != denseFileFormatVersion && header.Version != sparseFileFormatVersion {
+		return fmt.Errorf("Cannot load MC weights from version not %d or %d (%d)", denseFileFormatVersion, sparseFileFormatVersion, header.Version)
 	}
 	if int(header.ActionSpaceMagnitude) != length {
-		return fmt.Errorf("Cannot load MC weights from file size not %d (%d)", state.ActionSpaceMagnitude, header.ActionSpaceMagnitude)
+		return fmt.Errorf("Cannot load MC weights from file size not %d (%d)", length, header.ActionSpaceMagnitude)
 	}
 	qp.epsilon = header.Epsilon
 
+	count := length
+	if header.Version == sparseFileFormatVersion {
+		var stored uint64
+		if err := binary.Read(reader, binary.BigEndian, &stored); err != nil {
+			return err
+		}
+		count = int(stored)
+	}
+
 	by := make([]byte, 4)
-	for i := range qp.qf {
-		if i%(length/100) == 0 {
-			fmt.Printf("\rLoading %2d%%", i*100/length)
+	for n := 0; n < count; n++ {
+		if count >= 100 && n%(count/100) == 0 {
+			fmt.Printf("\rLoading %2d%%", n*100/count)
+		}
+		i := n
+		if header.Version == sparseFileFormatVersion {
+			var index uint32
+			if err := binary.Read(reader, binary.BigEndian, &index); err != nil {
+				return err
+			}
+			i = int(index)
+			if i >= length {
+				return fmt.Errorf("Cannot load MC weights with index %d out of range", i)
+			}
 		}
 
-		if _, err := reader.Read(by); err != nil {
+		if _, err := io.ReadFull(reader, by); err != nil {
 			return err
 		}
 
-		qp.qf[i] = Value{
-			sum:   uint16(by[0]) | uint16(by[1])<<8,
-			count: uint16(by[2]) | uint16(by[3])<<8,
+		// Only state-actions that were seen need to be stored
+		val := binary.LittleEndian.Uint32(by)
+		if val != qp.qf.Get(i) && !qp.qf.Set(i, val) {
+			return errors.New("The MC weights don't fit in the memory limit")
 		}
 	}
 	fmt.Printf("\rLoaded 100%%\n")
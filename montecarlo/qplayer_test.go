@@ -4,6 +4,10 @@ import (
 	"os"
 	"testing"
 
+	"love-letter-ai/players"
+	"love-letter-ai/qtable"
+	"love-letter-ai/state"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,10 +18,9 @@ func TestFileLoadSave(t *testing.T) {
 	qp := newTestQPlayer(epsilon, tableSize)
 
 	// To avoid using much RAM, only fill the first portion (but all will be written)
-	for i := range qp.qf {
+	for i := 0; i < tableSize; i++ {
 		// Fill with arbitrary data
-		qp.qf[i].sum = uint16(i)
-		qp.qf[i].count = uint16((i * 1293) % 289)
+		qp.qf.Set(i, Value{sum: uint16(i), count: uint16((i * 1293) % 289)}.pack())
 	}
 
 	err := qp.SaveToFile(path)
@@ -34,7 +37,34 @@ func TestFileLoadSave(t *testing.T) {
 
 func newTestQPlayer(epsilon float32, size int) *QPlayer {
 	return &QPlayer{
-		qf:      make([]Value, size, size),
+		qf:      qtable.NewDense(size, 0),
 		epsilon: epsilon,
 	}
 }
+
+func TestSparseQPlayer(t *testing.T) {
+	path := "temp-qplayer-sparse-test-file.dat"
+	defer os.Remove(path)
+
+	// The dense table would need 4GB
+	qp := NewQPlayerWithMemory(state.SimpleEncoder{}, 0.125, 1<<20)
+	assert.IsType(t, &qtable.Sparse{}, qp.qf)
+	qp.TrainWithPlayerPolicy(100, &players.RandomPlayer{})
+	stored, dropped := qp.Stored()
+	assert.NotZero(t, stored)
+	assert.Zero(t, dropped)
+
+	assert.NoError(t, qp.SaveToFile(path))
+	qp2 := NewQPlayerWithMemory(state.SimpleEncoder{}, 0, 1<<20)
+	assert.NoError(t, qp2.LoadFromFile(path))
+	assert.Equal(t, float32(0.125), qp2.epsilon)
+	qp.qf.Each(func(i int, v uint32) {
+		assert.Equal(t, v, qp2.qf.Get(i), "Value %d", i)
+	})
+}
+
+func TestPackValue(t *testing.T) {
+	v := Value{sum: 0x1234, count: 0xABCD}
+	assert.Equal(t, uint32(0xABCD1234), v.pack())
+	assert.Equal(t, v, unpack(v.pack()))
+}
@@ -15,6 +15,15 @@ type Value struct {
 	count uint16
 }
 
+// pack returns the value as 32 bits: the sum, then the count.
+func (v Value) pack() uint32 {
+	return uint32(v.sum) | uint32(v.count)<<16
+}
+
+func unpack(packed uint32) Value {
+	return Value{sum: uint16(packed), count: uint16(packed >> 16)}
+}
+
 type ValueFunction [state.SpaceMagnitude]Value
 type Action [state.SpaceMagnitude]uint8
 
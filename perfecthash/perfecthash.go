@@ -0,0 +1,131 @@
+// Package perfecthash maps a fixed set of keys to the numbers from 0 to n-1 (a minimal perfect hash), so values for
+// the keys can be stored densely.
+package perfecthash
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// gamma is the number of bits in each level for each key left. Larger is faster to build and look up, but uses
+	// more memory.
+	gamma = 2
+
+	// maxLevels is the number of levels before the keys left over are put in a map instead.
+	maxLevels = 32
+)
+
+// Hash is a minimal perfect hash built like BBHash: each level is a bit array with a bit set where exactly one of the
+// keys left hashes to, and the keys that collide move on to the next level. A key's number is the rank of its bit.
+// The keys are also stored, so other keys are rejected.
+type Hash struct {
+	levels []level
+	rest   map[uint32]int // The keys that collided in every level
+	keys   []uint32       // keys[i] is the key numbered i
+}
+
+type level struct {
+	bits []uint64
+
+	// ranks[w] is the number of the first bit set in bits[w], i.e. the bits set before it in this and earlier levels.
+	ranks []uint32
+}
+
+// New returns a Hash for the keys, which must be distinct. The same keys always get the same numbers, in any order.
+func New(keys []uint32) *Hash {
+	hash := &Hash{keys: make([]uint32, len(keys))}
+	left := keys
+	rank := 0
+	for l := 0; len(left) > 0 && l < maxLevels; l++ {
+		words := (gamma*len(left) + 63) / 64
+		seen := make([]uint64, words)
+		collided := make([]uint64, words)
+		for _, key := range left {
+			p := position(key, l, words)
+			if seen[p/64]&(1<<(p%64)) != 0 {
+				collided[p/64] |= 1 << (p % 64)
+			}
+			seen[p/64] |= 1 << (p % 64)
+		}
+
+		lvl := level{bits: seen, ranks: make([]uint32, words)}
+		for w := range lvl.bits {
+			lvl.bits[w] &^= collided[w]
+			lvl.ranks[w] = uint32(rank)
+			rank += bits.OnesCount64(lvl.bits[w])
+		}
+		hash.levels = append(hash.levels, lvl)
+
+		// The keys that collided are left for the next level
+		next := []uint32{}
+		for _, key := range left {
+			p := position(key, l, words)
+			if collided[p/64]&(1<<(p%64)) != 0 {
+				next = append(next, key)
+			} else {
+				hash.keys[lvl.rank(p)] = key
+			}
+		}
+		left = next
+	}
+
+	if len(left) > 0 {
+		sort.Slice(left, func(i, j int) bool { return left[i] < left[j] })
+		hash.rest = make(map[uint32]int, len(left))
+		for _, key := range left {
+			hash.rest[key] = rank
+			hash.keys[rank] = key
+			rank++
+		}
+	}
+	return hash
+}
+
+// Len returns the number of keys.
+func (hash *Hash) Len() int {
+	return len(hash.keys)
+}
+
+// Lookup returns the key's number, or false if the key isn't one of the keys the hash was built for.
+func (hash *Hash) Lookup(key uint32) (int, bool) {
+	for l, lvl := range hash.levels {
+		p := position(key, l, len(lvl.bits))
+		if lvl.bits[p/64]&(1<<(p%64)) != 0 {
+			i := lvl.rank(p)
+			return i, hash.keys[i] == key
+		}
+	}
+	i, ok := hash.rest[key]
+	return i, ok
+}
+
+// Key returns the key numbered i.
+func (hash *Hash) Key(i int) uint32 {
+	return hash.keys[i]
+}
+
+// Bytes returns roughly the memory used by the hash.
+func (hash *Hash) Bytes() int {
+	size := 4*len(hash.keys) + 16*len(hash.rest)
+	for _, lvl := range hash.levels {
+		size += 8*len(lvl.bits) + 4*len(lvl.ranks)
+	}
+	return size
+}
+
+// rank returns the number of the bit at p, which must be set.
+func (lvl level) rank(p uint) int {
+	before := lvl.bits[p/64] & (1<<(p%64) - 1)
+	return int(lvl.ranks[p/64]) + bits.OnesCount64(before)
+}
+
+// position returns the bit for the key in a level with the number of words, using a different hash for each level.
+func position(key uint32, l, words int) uint {
+	// splitmix64
+	h := uint64(key) + uint64(l+1)*0x9e3779b97f4a7c15
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	h ^= h >> 31
+	return uint(h % uint64(words*64))
+}
@@ -0,0 +1,62 @@
+package perfecthash
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	set := map[uint32]bool{}
+	keys := []uint32{}
+	for len(keys) < 100000 {
+		key := r.Uint32() % (1 << 26)
+		if !set[key] {
+			set[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	hash := New(keys)
+	assert.Equal(t, len(keys), hash.Len())
+	used := make([]bool, len(keys))
+	for _, key := range keys {
+		i, ok := hash.Lookup(key)
+		assert.True(t, ok)
+		assert.False(t, used[i], "Number %d was used twice", i)
+		used[i] = true
+		assert.Equal(t, key, hash.Key(i))
+	}
+
+	for key := uint32(0); key < 100000; key++ {
+		_, ok := hash.Lookup(key)
+		assert.Equal(t, set[key], ok, "Key %d", key)
+	}
+	assert.True(t, hash.Bytes() < 6*len(keys), "The hash uses %d bytes", hash.Bytes())
+
+	// The numbers don't depend on the order of the keys
+	r.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	shuffled := New(keys)
+	for _, key := range keys {
+		i, _ := hash.Lookup(key)
+		j, _ := shuffled.Lookup(key)
+		if i != j {
+			// Collisions within a level don't depend on the order, so the ranks don't either
+			t.Fatalf("Key %d is %d, then %d", key, i, j)
+		}
+	}
+}
+
+func TestEmptyAndTinyHashes(t *testing.T) {
+	empty := New(nil)
+	assert.Zero(t, empty.Len())
+	_, ok := empty.Lookup(3)
+	assert.False(t, ok)
+
+	one := New([]uint32{42})
+	i, ok := one.Lookup(42)
+	assert.True(t, ok)
+	assert.Zero(t, i)
+}
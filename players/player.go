@@ -11,3 +11,9 @@ type Player interface {
 	PlayCard(state.Simple) rules.Action
 	PlayCardRand(state.Simple, *rand.Rand) rules.Action
 }
+
+// ViewPlayer is implemented by players that choose an action from everything they're allowed to know, instead of a
+// state.Simple. The Gamemaster uses PlayView when it's available.
+type ViewPlayer interface {
+	PlayView(rules.PlayerView) rules.Action
+}
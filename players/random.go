@@ -16,7 +16,7 @@ func (rp *RandomPlayer) PlayCard(state state.Simple) rules.Action {
 	action := rules.Action{
 		PlayRecent:         rand.Int31n(1) == 0,
 		SelectedCard:       rules.Card(rand.Int31n(int32(rules.Princess))),
-		TargetPlayerOffset: int(rand.Int31n(1) + 1), // This assumes two players
+		TargetPlayerOffset: state.OpponentOffset,
 	}
 
 	return playAction(state, action)
@@ -26,7 +26,7 @@ func (rp *RandomPlayer) PlayCardRand(state state.Simple, r *rand.Rand) rules.Act
 	action := rules.Action{
 		PlayRecent:         r.Int31n(1) == 0,
 		SelectedCard:       rules.Card(r.Int31n(int32(rules.Princess))),
-		TargetPlayerOffset: int(r.Int31n(1) + 1), // This assumes two players
+		TargetPlayerOffset: state.OpponentOffset,
 	}
 
 	return playAction(state, action)
@@ -40,8 +40,7 @@ func playAction(state state.Simple, action rules.Action) rules.Action {
 		otherCard = state.RecentDraw
 	}
 
-	// This assumes 2 players
-	otherPlayerOffset := 1
+	otherPlayerOffset := state.OpponentOffset
 
 	if playedCard == rules.Princess {
 		action.PlayRecent = !action.PlayRecent
@@ -14,14 +14,17 @@ import (
 type TrainingPlayer interface {
 	Player
 
-	// GreedyAction returns the greedy action for the given state, along with the corresponding state-action.
-	// The action may be nil if nothing has trained yet.
-	GreedyAction(state int) (*rules.Action, int)
+	// Encoder returns the encoder for the states passed to GreedyAction and UpdateQ.
+	Encoder() state.StateEncoder
+
+	// GreedyAction returns the greedy action for the given state, out of the actions in the mask, along with the
+	// corresponding state-action. The action may be nil if nothing has trained yet.
+	GreedyAction(state int, mask state.ActionMask) (*rules.Action, int)
 
 	// UpdateQ is called to update the player's state-action values.
 	// qStates is a slice of state-action ints representing the states seen
-	// (and actions chosen) so far by this player.
-	UpdateQ(gameEnded bool, qStates []int, rewards []float32)
+	// (and actions chosen) so far by this player, and masks has the legal actions in each of those states.
+	UpdateQ(gameEnded bool, qStates []int, masks []state.ActionMask, rewards []float32)
 
 	// Finalize is called at the end in case any cleanup is necessary.
 	Finalize()
@@ -35,8 +38,14 @@ type trainer struct {
 	// (and actions chosen) so far by this player.
 	qStates []int
 
+	// masks has the legal actions for each of the qStates.
+	masks []state.ActionMask
+
 	// rewards is a slice of all rewards received so far.
 	rewards []float32
+
+	// lossWasStupid is true if this player lost the current game by making a stupid play.
+	lossWasStupid bool
 }
 
 const (
@@ -57,6 +66,8 @@ var (
 	Output  = true
 )
 
+// Train plays episodes games between the provided players, updating each one as it goes.
+// The number of players in each game is the number of players provided.
 func Train(pls []TrainingPlayer, episodes int, epsilon float64) {
 	wg := sync.WaitGroup{}
 	in := make(chan int)
@@ -72,35 +83,52 @@ func Train(pls []TrainingPlayer, episodes int, epsilon float64) {
 
 			r := rand.New(rand.NewSource(seed))
 			for games := range in {
-				templateSG, err := rules.NewGame(2, r)
+				templateSG, err := rules.NewGame(len(pls), r)
 				if err != nil {
 					panic(err.Error())
 				}
 				for i := 0; i < games; i++ {
 					sg := templateSG.Copy()
 
-					trs[0].qStates = make([]int, 0, 8) // I think maximum number of turns is 6, but whatever
-					trs[1].qStates = make([]int, 0, 8)
-					trs[0].rewards = make([]float32, 0, 8)
-					trs[1].rewards = make([]float32, 0, 8)
+					for i := range trs {
+						trs[i].qStates = make([]int, 0, 8) // I think maximum number of turns is 6, but whatever
+						trs[i].masks = make([]state.ActionMask, 0, 8)
+						trs[i].rewards = make([]float32, 0, 8)
+						trs[i].lossWasStupid = false
+					}
+					forfeit := false
 
 					for !sg.GameEnded {
-						action, err := trs[sg.ActivePlayer].learningAction(sg, epsilon, r)
+						pid := sg.ActivePlayer
+						action, err := trs[pid].learningAction(sg, epsilon, r)
 						if err != nil {
 							panic(err.Error())
 						}
 
+						// LossWasStupid stays set for the rest of the game, so clear it to find out if this play was stupid.
+						sg.LossWasStupid = false
 						sg.PlayCard(action, r)
+						if sg.LossWasStupid {
+							// Only the active player can make a stupid play
+							trs[pid].lossWasStupid = true
+							forfeit = true
+						}
 					}
 
-					// Now allow both players to update based on the end of the game.
-					if sg.LossWasStupid {
-						// This only happens if the play is something that will ALWAYS lose the game, so incur a huge penalty
-						trs[(sg.Winner+1)%2].updateQ(sg.GameEnded, state.TerminalState, stupidReward)
-						trs[sg.Winner].updateQ(sg.GameEnded, state.TerminalState, forfeitWinReward)
-					} else {
-						trs[(sg.Winner+1)%2].updateQ(sg.GameEnded, state.TerminalState, lossReward)
-						trs[sg.Winner].updateQ(sg.GameEnded, state.TerminalState, winReward)
+					// Now allow all players to update based on the end of the game.
+					for pid := range trs {
+						switch {
+						case trs[pid].lossWasStupid:
+							// This only happens if the play is something that will ALWAYS lose the game, so incur a huge penalty
+							trs[pid].updateQ(sg.GameEnded, trs[pid].terminalState(), 0, stupidReward)
+						case sg.IsWinner(pid) && forfeit:
+							trs[pid].updateQ(sg.GameEnded, trs[pid].terminalState(), 0, forfeitWinReward/float32(len(sg.Winners)))
+						case sg.IsWinner(pid):
+							// Players who share a win split the reward
+							trs[pid].updateQ(sg.GameEnded, trs[pid].terminalState(), 0, winReward/float32(len(sg.Winners)))
+						default:
+							trs[pid].updateQ(sg.GameEnded, trs[pid].terminalState(), 0, lossReward)
+						}
 					}
 				}
 				out <- games
@@ -131,8 +159,9 @@ func Train(pls []TrainingPlayer, episodes int, epsilon float64) {
 	close(out)
 	wg.Wait()
 
-	pls[0].Finalize()
-	pls[1].Finalize()
+	for _, pl := range pls {
+		pl.Finalize()
+	}
 
 	if Output {
 		fmt.Fprintln(os.Stderr, "\r100.0% complete")
@@ -152,35 +181,45 @@ func status(episodes, epPrintMod int, ch chan int) {
 	}
 }
 
-// epsilonGreedyAction provides a suggested action for the provided state.
+// epsilonGreedyAction provides a suggested action for the active player's view, out of the legal actions in the mask
+// (see state.EncodedMask).
 // If it hasn't learned anything for this state, it plays randomly.
 // It will also choose a random action with probability Epsilon. This isn't exactly
 // Epsilon-greedy because it doesn't subtract the probability of the greedy action.
-func epsilonGreedyAction(pl TrainingPlayer, st state.Simple, epsilon float64, r *rand.Rand) (rules.Action, int) {
-	sNoAct := st.AsIndex()
-	act, sa := pl.GreedyAction(sNoAct)
+func epsilonGreedyAction(pl TrainingPlayer, view rules.PlayerView, mask state.ActionMask, epsilon float64, r *rand.Rand) (rules.Action, int) {
+	enc := pl.Encoder()
+	act, sa := pl.GreedyAction(enc.Index(view), mask)
 	if act == nil || r.Float64() < epsilon {
-		action := (&RandomPlayer{}).PlayCardRand(st, r)
-		return action, state.IndexWithAction(sNoAct, action)
+		action := state.DecodeAction(enc, view, rules.ActionFromInt(mask.Random(r)))
+		sa, _ := state.EncodeAction(enc, view, action)
+		return action, sa
 	}
-	return *act, sa
+	return state.DecodeAction(enc, view, *act), sa
 }
 
 // learningAction provides a suggested action for the provided state.
 // However, it also assumes it's being called for each play in a game so it can update the policy.
 func (tr *trainer) learningAction(game rules.Gamestate, epsilon float64, r *rand.Rand) (rules.Action, error) {
-	action, sa := epsilonGreedyAction(tr.tp, state.NewSimple(game), epsilon, r)
-	tr.updateQ(game.GameEnded, sa, noReward)
+	view := game.ViewFor(game.ActivePlayer)
+	mask := state.EncodedMask(tr.tp.Encoder(), view)
+	action, sa := epsilonGreedyAction(tr.tp, view, mask, epsilon, r)
+	tr.updateQ(game.GameEnded, sa, mask, noReward)
 	return action, nil
 }
 
-func (tr *trainer) updateQ(gameEnded bool, sa int, reward float32) {
+// terminalState returns the state-action recorded at the end of the game. Its value is never used.
+func (tr *trainer) terminalState() int {
+	return tr.tp.Encoder().Size() - 1
+}
+
+func (tr *trainer) updateQ(gameEnded bool, sa int, mask state.ActionMask, reward float32) {
 	tr.qStates = append(tr.qStates, sa)
+	tr.masks = append(tr.masks, mask)
 	tr.rewards = append(tr.rewards, reward)
 
 	numStates := len(tr.qStates)
 	// Now save the update
 	if numStates > 1 {
-		tr.tp.UpdateQ(gameEnded, tr.qStates, tr.rewards)
+		tr.tp.UpdateQ(gameEnded, tr.qStates, tr.masks, tr.rewards)
 	}
 }
@@ -0,0 +1,180 @@
+package qtable
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	shardBits = 6
+	numShards = 1 << shardBits
+
+	// minSlots is the number of slots each shard starts with
+	minSlots = 16
+
+	// slotBytes is the memory used by each slot: the key and the value
+	slotBytes = 8
+)
+
+// Sparse only stores the values that were set, in hash tables that grow until they reach the memory limit. After that,
+// values at new indices are dropped (and stay at the default), but stored values can still change.
+// It's safe to use from several goroutines.
+type Sparse struct {
+	size     int
+	def      uint32
+	maxSlots int // The most slots each shard can grow to, or 0 for no limit
+	dropped  int64
+	shards   [numShards]shard
+}
+
+type shard struct {
+	sync.RWMutex
+
+	// keys holds the index+1 in each slot, so 0 means the slot is empty.
+	keys   []uint32
+	values []uint32
+	count  int
+}
+
+// NewSparse returns a Sparse table using at most about maxBytes (or 0 for no limit) where unset values are def.
+func NewSparse(size int, def uint32, maxBytes int) *Sparse {
+	if size < 0 || size >= math.MaxUint32 {
+		panic("The size of a sparse table must fit in 32 bits")
+	}
+	table := &Sparse{size: size, def: def}
+	if maxBytes > 0 {
+		table.maxSlots = minSlots
+		for table.maxSlots*2*slotBytes*numShards <= maxBytes {
+			table.maxSlots *= 2
+		}
+	}
+	for i := range table.shards {
+		table.shards[i].keys = make([]uint32, minSlots)
+		table.shards[i].values = make([]uint32, minSlots)
+	}
+	return table
+}
+
+func (table *Sparse) Len() int { return table.size }
+
+func (table *Sparse) Get(i int) uint32 {
+	sh, h := table.shardFor(i)
+	sh.RLock()
+	defer sh.RUnlock()
+	if slot, found := sh.find(h, uint32(i)+1); found {
+		return sh.values[slot]
+	}
+	return table.def
+}
+
+// has returns true if a value is stored at the index.
+func (table *Sparse) has(i int) bool {
+	sh, h := table.shardFor(i)
+	sh.RLock()
+	defer sh.RUnlock()
+	_, found := sh.find(h, uint32(i)+1)
+	return found
+}
+
+func (table *Sparse) Set(i int, v uint32) bool {
+	sh, h := table.shardFor(i)
+	sh.Lock()
+	defer sh.Unlock()
+
+	key := uint32(i) + 1
+	slot, found := sh.find(h, key)
+	if found {
+		sh.values[slot] = v
+		return true
+	}
+
+	if (sh.count+1)*4 > len(sh.keys)*3 {
+		// Too full for quick lookups, so grow if there's room
+		if table.maxSlots != 0 && len(sh.keys) >= table.maxSlots {
+			if sh.count+1 >= len(sh.keys) {
+				// Keep one slot empty so lookups always end
+				atomic.AddInt64(&table.dropped, 1)
+				return false
+			}
+		} else {
+			sh.grow()
+			slot, _ = sh.find(h, key)
+		}
+	}
+	sh.keys[slot] = key
+	sh.values[slot] = v
+	sh.count++
+	return true
+}
+
+func (table *Sparse) Each(f func(i int, v uint32)) {
+	for s := range table.shards {
+		sh := &table.shards[s]
+		sh.RLock()
+		for slot, key := range sh.keys {
+			if key != 0 {
+				f(int(key-1), sh.values[slot])
+			}
+		}
+		sh.RUnlock()
+	}
+}
+
+func (table *Sparse) Stored() int {
+	count := 0
+	for s := range table.shards {
+		sh := &table.shards[s]
+		sh.RLock()
+		count += sh.count
+		sh.RUnlock()
+	}
+	return count
+}
+
+func (table *Sparse) Dropped() int {
+	return int(atomic.LoadInt64(&table.dropped))
+}
+
+// shardFor returns the shard for the index, and the hash for finding its slot.
+func (table *Sparse) shardFor(i int) (*shard, uint64) {
+	h := hash(i)
+	return &table.shards[h%numShards], h >> shardBits
+}
+
+// hash spreads out nearby indices, using the finalizer from MurmurHash3.
+func hash(i int) uint64 {
+	h := uint64(i)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
+
+// find returns the slot with the key, or the empty slot where it belongs, using linear probing.
+func (sh *shard) find(h uint64, key uint32) (int, bool) {
+	mask := len(sh.keys) - 1
+	for slot := int(h) & mask; ; slot = (slot + 1) & mask {
+		switch sh.keys[slot] {
+		case key:
+			return slot, true
+		case 0:
+			return slot, false
+		}
+	}
+}
+
+// grow doubles the number of slots.
+func (sh *shard) grow() {
+	keys, values := sh.keys, sh.values
+	sh.keys = make([]uint32, 2*len(keys))
+	sh.values = make([]uint32, 2*len(values))
+	for slot, key := range keys {
+		if key == 0 {
+			continue
+		}
+		newSlot, _ := sh.find(hash(int(key-1))>>shardBits, key)
+		sh.keys[newSlot] = key
+		sh.values[newSlot] = values[slot]
+	}
+}
@@ -0,0 +1,98 @@
+// Package qtable stores a value for each state-action, either densely or sparsely within a memory limit.
+// Values are 32 bits, so agents convert them, e.g. with math.Float32bits.
+package qtable
+
+// Table stores a value for each index from 0 to Len()-1. Values that were never set are the table's default.
+type Table interface {
+	// Len returns the number of indices.
+	Len() int
+
+	// Get returns the value at the index.
+	Get(i int) uint32
+
+	// Set stores the value at the index. It returns false if the value couldn't be stored because the table is full.
+	Set(i int, v uint32) bool
+
+	// Each calls f for every stored value. f must not modify the table.
+	Each(f func(i int, v uint32))
+
+	// Stored returns the number of stored values.
+	Stored() int
+
+	// Dropped returns the number of values that weren't stored because the table was full.
+	Dropped() int
+}
+
+// New returns a Dense table if it fits in maxBytes (or maxBytes is 0), and a Sparse table limited to maxBytes if not.
+func New(size int, def uint32, maxBytes int) Table {
+	if maxBytes <= 0 || size*4 <= maxBytes {
+		return NewDense(size, def)
+	}
+	return NewSparse(size, def, maxBytes)
+}
+
+// Dense stores every value, using 4 bytes for each.
+type Dense []uint32
+
+// NewDense returns a Dense table with every value set to def.
+func NewDense(size int, def uint32) Dense {
+	table := make(Dense, size, size)
+	if def != 0 {
+		for i := range table {
+			table[i] = def
+		}
+	}
+	return table
+}
+
+func (table Dense) Len() int         { return len(table) }
+func (table Dense) Get(i int) uint32 { return table[i] }
+func (table Dense) Stored() int      { return len(table) }
+func (table Dense) Dropped() int     { return 0 }
+
+func (table Dense) Set(i int, v uint32) bool {
+	table[i] = v
+	return true
+}
+
+// Each calls f for every value, since they're all stored.
+func (table Dense) Each(f func(i int, v uint32)) {
+	for i, v := range table {
+		f(i, v)
+	}
+}
+
+// Merge sets every value in dst to f(its value, the value in src), returning false if some didn't fit. Values stored
+// in neither table aren't changed, so f should return dst's default when given both defaults.
+func Merge(dst, src Table, f func(a, b uint32) uint32) bool {
+	ok := true
+	src.Each(func(i int, v uint32) {
+		ok = dst.Set(i, f(dst.Get(i), v)) && ok
+	})
+
+	sparse, isSparse := src.(*Sparse)
+	if !isSparse {
+		// Every value was in src
+		return ok
+	}
+	// Now merge the values only stored in dst. They can't be set while iterating over dst unless it's dense.
+	type update struct {
+		i int
+		v uint32
+	}
+	updates := []update{}
+	dst.Each(func(i int, v uint32) {
+		if sparse.has(i) {
+			return
+		}
+		if dense, isDense := dst.(Dense); isDense {
+			dense[i] = f(v, sparse.def)
+		} else {
+			updates = append(updates, update{i, f(v, sparse.def)})
+		}
+	})
+	for _, u := range updates {
+		ok = dst.Set(u.i, u.v) && ok
+	}
+	return ok
+}
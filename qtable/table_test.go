@@ -0,0 +1,88 @@
+package qtable
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChoosesStorage(t *testing.T) {
+	assert.IsType(t, Dense{}, New(100, 7, 0))
+	assert.IsType(t, Dense{}, New(100, 7, 400))
+	assert.IsType(t, &Sparse{}, New(100, 7, 399))
+}
+
+func TestTables(t *testing.T) {
+	for name, table := range map[string]Table{
+		"dense":  NewDense(5000, 7),
+		"sparse": NewSparse(5000, 7, 0),
+	} {
+		assert.Equal(t, 5000, table.Len(), name)
+		assert.Equal(t, uint32(7), table.Get(4999), name)
+
+		for i := 0; i < 5000; i += 3 {
+			assert.True(t, table.Set(i, uint32(i*i)), name)
+		}
+		assert.True(t, table.Set(3, 2), name)
+		for i := 0; i < 5000; i++ {
+			switch {
+			case i == 3:
+				assert.Equal(t, uint32(2), table.Get(i), name)
+			case i%3 == 0:
+				assert.Equal(t, uint32(i*i), table.Get(i), name)
+			default:
+				assert.Equal(t, uint32(7), table.Get(i), name)
+			}
+		}
+
+		if _, ok := table.(*Sparse); ok {
+			assert.Equal(t, 1667, table.Stored(), name)
+		}
+		seen := map[int]uint32{}
+		table.Each(func(i int, v uint32) { seen[i] = v })
+		assert.Len(t, seen, table.Stored(), name)
+		assert.Equal(t, uint32(81), seen[9], name)
+		assert.Zero(t, table.Dropped(), name)
+	}
+}
+
+func TestSparseMemoryLimit(t *testing.T) {
+	maxBytes := 64 * 1024
+	table := NewSparse(1<<30, 0, maxBytes)
+	for i := 0; i < 100000; i++ {
+		table.Set(i*1000, uint32(i)+1)
+	}
+	assert.True(t, table.Stored()*slotBytes <= maxBytes, "Stored %d values", table.Stored())
+	assert.Equal(t, 100000, table.Stored()+table.Dropped())
+
+	// Stored values can still change, but others are dropped
+	stored := -1
+	table.Each(func(i int, v uint32) { stored = i })
+	assert.True(t, table.Set(stored, 1))
+	assert.Equal(t, uint32(1), table.Get(stored))
+	assert.False(t, table.Set(1, 1))
+	assert.Zero(t, table.Get(1))
+}
+
+func TestSparseConcurrent(t *testing.T) {
+	table := NewSparse(1<<20, 0, 0)
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			for i := g; i < 1<<16; i += 8 {
+				table.Set(i*11, uint32(i))
+			}
+			wg.Done()
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1<<16, table.Stored())
+	for i := 0; i < 1<<16; i++ {
+		if table.Get(i*11) != uint32(i) {
+			t.Fatalf("Value %d was lost", i)
+		}
+	}
+}
@@ -7,12 +7,22 @@ type Action struct {
 	// TargetPlayerOffset is set (targetPlayerID - myID + numPlayers)%numPlayers.
 	TargetPlayerOffset int
 
+	// SecondTargetOffset is the offset of the second player targeted by cards that can target two (like the Cardinal).
+	// For cards that target one or two opponents, 0 means there is no second target.
+	SecondTargetOffset int
+
 	// SelectedCard is set to the Card chosen by the action, if applicable.
 	SelectedCard Card
+
+	// KeepCard is the card kept when resolving a Chancellor. The other cards are put on the bottom of the deck.
+	KeepCard Card
+
+	// BottomCard is the card put on the very bottom of the deck when resolving a Chancellor that returns two cards.
+	BottomCard Card
 }
 
 // AsInt converts an action to an integer to be used for indexing.
-// This integer only uses 4 bits and is only valid for 2 players.
+// This integer only uses 4 bits and is only valid for 2 players with ClassicCards.
 // TargetPlayer is instead encoded as a bool: targetSelf. That never conflicts with SelectedCard.
 func (act Action) AsInt() int {
 	retVal := 0
@@ -45,6 +55,45 @@ func ActionFromInt(st int) Action {
 	return act
 }
 
+// ActionSpaceSize returns the number of integers that AsIntForPlayers can return for the given number of players.
+func ActionSpaceSize(numPlayers int) int {
+	if numPlayers <= 2 {
+		return 16
+	}
+	return 16 * numPlayers
+}
+
+// AsIntForPlayers converts an action to an integer to be used for indexing in a game with numPlayers players.
+// For 2 players this is the same as AsInt. Otherwise the lower 4 bits encode PlayRecent and SelectedCard (as in AsInt
+// for a Guard), and the higher bits encode the TargetPlayerOffset.
+func (act Action) AsIntForPlayers(numPlayers int) int {
+	if numPlayers <= 2 {
+		return act.AsInt()
+	}
+	retVal := 0
+	if act.PlayRecent {
+		retVal = 1
+	}
+	if act.SelectedCard != None && act.SelectedCard != Guard {
+		retVal += 2 * (int(act.SelectedCard) - 1)
+	}
+	return retVal + 16*act.TargetPlayerOffset
+}
+
+// ActionFromIntForPlayers reverses action.AsIntForPlayers.
+func ActionFromIntForPlayers(st int, numPlayers int) Action {
+	if numPlayers <= 2 {
+		return ActionFromInt(st)
+	}
+	act := Action{}
+	if st%2 == 1 {
+		act.PlayRecent = true
+	}
+	act.SelectedCard = Card((st&0xF)>>1 + 1)
+	act.TargetPlayerOffset = st >> 4
+	return act
+}
+
 func (card Card) PossibleActions(isRecent bool) []Action {
 	switch card {
 	case Guard:
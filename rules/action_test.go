@@ -41,3 +41,29 @@ func TestActionConversion(t *testing.T) {
 		assert.EqualValues(t, test.converted, ActionFromInt(test.action.AsInt()), "Convert: "+test.descr)
 	}
 }
+
+var multiplayerTests = []struct {
+	descr     string
+	action    Action
+	out       int
+	converted Action
+}{
+	{"Guard guess", Action{PlayRecent: true, TargetPlayerOffset: 2, SelectedCard: Princess}, 15 + 32, Action{PlayRecent: true, TargetPlayerOffset: 2, SelectedCard: Princess}},
+	{"Target 3", Action{PlayRecent: false, TargetPlayerOffset: 3}, 48, Action{PlayRecent: false, TargetPlayerOffset: 3, SelectedCard: Guard}},
+	{"Target self", Action{PlayRecent: true}, 1, Action{PlayRecent: true, SelectedCard: Guard}},
+}
+
+func TestMultiplayerActionConversion(t *testing.T) {
+	for _, test := range multiplayerTests {
+		assert.EqualValues(t, test.out, test.action.AsIntForPlayers(4), "To int: "+test.descr)
+		assert.True(t, test.action.AsIntForPlayers(4) < ActionSpaceSize(4), "Size: "+test.descr)
+		assert.EqualValues(t, test.converted, ActionFromIntForPlayers(test.out, 4), "Convert: "+test.descr)
+	}
+}
+
+func TestTwoPlayerActionConversionUnchanged(t *testing.T) {
+	for _, test := range tests {
+		assert.EqualValues(t, test.out, test.action.AsIntForPlayers(2), "To int: "+test.descr)
+		assert.EqualValues(t, test.converted, ActionFromIntForPlayers(test.out, 2), "Convert: "+test.descr)
+	}
+}
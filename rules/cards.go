@@ -0,0 +1,335 @@
+package rules
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Targeting describes which players a card can target.
+type Targeting int
+
+const (
+	// NoTarget cards don't target anyone.
+	NoTarget = Targeting(iota)
+
+	// TargetOpponent cards must target another player who is still in the game.
+	TargetOpponent
+
+	// GuessOpponent cards must target another player who is still in the game and select a card (like the Guard).
+	GuessOpponent
+
+	// TargetAnyPlayer cards can target any player who is still in the game, including the active player.
+	TargetAnyPlayer
+
+	// TargetOneOrTwoOpponents cards must target one other player, and can also target a second (like the Baroness).
+	TargetOneOrTwoOpponents
+
+	// TargetTwoPlayers cards must target two different players, which can include the active player (like the Cardinal).
+	TargetTwoPlayers
+)
+
+// CardDefinition describes how a card behaves. Most definitions embed a BaseCard, which provides defaults for
+// everything except the effect of playing the card.
+type CardDefinition interface {
+	// Name is the name of the card.
+	Name() string
+
+	// Value is the value used to compare cards (e.g. by a Baron or at the end of the game).
+	Value() int
+
+	// Count is the number of copies of the card in the deck.
+	Count() int
+
+	// Targeting describes which players the card can target.
+	Targeting() Targeting
+
+	// MustBePlayedInsteadOf returns true if this card must be played when the other card in hand is the provided card.
+	MustBePlayedInsteadOf(card Card) bool
+
+	// Protects returns true if a player whose last play was this card can't be targeted.
+	Protects() bool
+
+	// EliminatesWhenDiscarded returns true if a player who is forced to discard this card is eliminated.
+	EliminatesWhenDiscarded() bool
+
+	// Play applies the effect of the active player playing this card, after it has been discarded.
+	// For cards that target a player, target is the id of that player. For other cards, it is the active player.
+	// Cards that can target two players find the second in the action's SecondTargetOffset.
+	// If a card targets an opponent but every opponent is protected, Play isn't called.
+	Play(state *Gamestate, action Action, target int, r *rand.Rand)
+}
+
+// Resolver is implemented by cards that need another action from the active player after being played (like the
+// Chancellor). Play should set the Gamestate's PendingCard to the card, and the next action is passed to Resolve.
+type Resolver interface {
+	// Resolutions returns all legal actions to resolve the pending card.
+	Resolutions(state Gamestate) []Action
+
+	// Resolve applies the provided action, which is one of the Resolutions.
+	Resolve(state *Gamestate, action Action, r *rand.Rand)
+}
+
+// Guesser is implemented by GuessOpponent cards that can't guess every other card in the set (like the Premium Guard,
+// which guesses a value).
+type Guesser interface {
+	// Guesses returns the cards that can be selected when playing this card.
+	Guesses(cards *CardSet) []Card
+}
+
+// KnockoutHandler is implemented by cards that do something when a player with the card in their discard pile is
+// eliminated (like the Constable).
+type KnockoutHandler interface {
+	// KnockedOut is called when the player is eliminated, before the card in their hand is discarded.
+	KnockedOut(state *Gamestate, player int)
+}
+
+// ValueModifier is implemented by cards that change the value of a player's hand at the end of the game (like the Count).
+type ValueModifier interface {
+	// ModifyValue returns the value of the player's hand at the end of the game, given its value before this card's change.
+	ModifyValue(state Gamestate, player int, value int) int
+}
+
+// BonusAwarder is implemented by cards that award bonus tokens at the end of a game (like the Spy).
+type BonusAwarder interface {
+	// Bonus returns the number of bonus tokens this card awards to each player.
+	Bonus(state Gamestate) []int
+}
+
+// BaseCard is a card with no special properties and no effect.
+type BaseCard struct {
+	name      string
+	value     int
+	count     int
+	targeting Targeting
+}
+
+func NewBaseCard(name string, value, count int, targeting Targeting) BaseCard {
+	return BaseCard{
+		name:      name,
+		value:     value,
+		count:     count,
+		targeting: targeting,
+	}
+}
+
+func (bc BaseCard) Name() string                                                   { return bc.name }
+func (bc BaseCard) Value() int                                                     { return bc.value }
+func (bc BaseCard) Count() int                                                     { return bc.count }
+func (bc BaseCard) Targeting() Targeting                                           { return bc.targeting }
+func (bc BaseCard) MustBePlayedInsteadOf(card Card) bool                           { return false }
+func (bc BaseCard) Protects() bool                                                 { return false }
+func (bc BaseCard) EliminatesWhenDiscarded() bool                                  { return false }
+func (bc BaseCard) Play(state *Gamestate, action Action, target int, r *rand.Rand) {}
+
+// CardSet is a collection of card definitions that make up a deck.
+type CardSet struct {
+	// Name identifies the CardSet in the registry.
+	Name string
+
+	// MinPlayers and MaxPlayers are the range of players that can play with this set.
+	MinPlayers, MaxPlayers int
+
+	// TokensToWin is the number of tokens of affection needed to win a match, for each number of players.
+	// If it's nil, or doesn't include a number of players, the classic targets are used (see TokensNeeded).
+	TokensToWin map[int]int
+
+	definitions map[Card]CardDefinition
+
+	// cards is the list of cards in the order they were registered.
+	cards []Card
+}
+
+func NewCardSet(name string, minPlayers, maxPlayers int) *CardSet {
+	return &CardSet{
+		Name:        name,
+		MinPlayers:  minPlayers,
+		MaxPlayers:  maxPlayers,
+		definitions: map[Card]CardDefinition{},
+	}
+}
+
+// Register adds the definition of a card to the set, replacing any previous definition.
+func (cs *CardSet) Register(card Card, def CardDefinition) {
+	if card <= None || card >= numberOfCards {
+		panic(fmt.Sprintf("Card %d is out of range", card))
+	}
+	if _, ok := cs.definitions[card]; !ok {
+		cs.cards = append(cs.cards, card)
+	}
+	cs.definitions[card] = def
+	if _, ok := nameOfCard[card]; !ok {
+		nameOfCard[card] = def.Name()
+	}
+}
+
+// Definition returns the definition of the card, or nil if it isn't in the set.
+func (cs *CardSet) Definition(card Card) CardDefinition {
+	return cs.definitions[card]
+}
+
+// Cards returns all cards in the set in the order they were registered.
+func (cs *CardSet) Cards() []Card {
+	cards := make([]Card, len(cs.cards))
+	copy(cards, cs.cards)
+	return cards
+}
+
+// Deck returns a full deck of the cards in the set.
+func (cs *CardSet) Deck() Deck {
+	deck := Deck{}
+	for card, def := range cs.definitions {
+		deck[card] = def.Count()
+	}
+	return deck
+}
+
+// Value returns the value of the card, or 0 if it isn't in the set.
+func (cs *CardSet) Value(card Card) int {
+	def := cs.definitions[card]
+	if def == nil {
+		return 0
+	}
+	return def.Value()
+}
+
+// CardsWithValue returns one card for each distinct value in the set (the first one registered), except for the provided values.
+func (cs *CardSet) CardsWithValue(except ...int) []Card {
+	seen := map[int]bool{}
+	for _, val := range except {
+		seen[val] = true
+	}
+	cards := []Card{}
+	for _, card := range cs.cards {
+		val := cs.Value(card)
+		if !seen[val] {
+			seen[val] = true
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
+
+// classicTokensToWin are the tokens needed to win a match with the classic rules, which allow up to 4 players.
+var classicTokensToWin = map[int]int{2: 7, 3: 5, 4: 4}
+
+// TokensNeeded returns the number of tokens of affection needed to win a match with the provided number of players.
+// Without a target in TokensToWin, the classic targets are used, and 3 tokens for more than 4 players.
+func (cs *CardSet) TokensNeeded(numPlayers int) int {
+	if tokens, ok := cs.TokensToWin[numPlayers]; ok {
+		return tokens
+	}
+	if tokens, ok := classicTokensToWin[numPlayers]; ok {
+		return tokens
+	}
+	if numPlayers > 4 {
+		return 3
+	}
+	return classicTokensToWin[2]
+}
+
+// Score returns the sum of the values of the cards in the stack.
+func (cs *CardSet) Score(stack Stack) int {
+	sum := 0
+	for _, card := range stack {
+		sum += cs.Value(card)
+	}
+	return sum
+}
+
+var cardSets = map[string]*CardSet{}
+
+// RegisterCardSet makes the CardSet available by name, replacing any set with the same name.
+func RegisterCardSet(cs *CardSet) {
+	cardSets[cs.Name] = cs
+}
+
+// CardSetNamed returns the registered CardSet with the provided name.
+func CardSetNamed(name string) (*CardSet, error) {
+	cs, ok := cardSets[name]
+	if !ok {
+		return nil, fmt.Errorf("No card set is registered as '%s'", name)
+	}
+	return cs, nil
+}
+
+// cards returns the CardSet used by this game.
+func (state *Gamestate) cards() *CardSet {
+	if state.CardSet == nil {
+		return ClassicCards
+	}
+	return state.CardSet
+}
+
+// isProtected returns true if the player's last play protects them from being targeted.
+func (state *Gamestate) isProtected(player int) bool {
+	def := state.cards().Definition(state.LastPlay[player])
+	return def != nil && def.Protects()
+}
+
+// EliminatePlayer removes the player from the game. It's intended to be used by card effects.
+func (state *Gamestate) EliminatePlayer(player int, cause EliminationCause) {
+	state.eliminatePlayer(player, cause)
+}
+
+// ForceDiscard makes the player discard the card in their hand and draw a new one. It's intended to be used by card
+// effects. If the discarded card eliminates the player (like the Princess), no card is drawn.
+func (state *Gamestate) ForceDiscard(player int, r *rand.Rand) {
+	card := state.CardInHand[player]
+	def := state.cards().Definition(card)
+	state.RecordEvent(PrinceDiscard{Player: player, Card: card})
+	if def != nil && def.EliminatesWhenDiscarded() {
+		// Do this first to update FinalState.
+		state.eliminatePlayer(player, EliminatedByDiscard)
+	} else {
+		state.Discards[player] = append(state.Discards[player], card)
+		state.CardInHand[player] = state.Draw(r)
+	}
+	state.clearKnownCard(player, card)
+}
+
+// AwardToken gives the player a bonus token. It's intended to be used by card effects.
+func (state *Gamestate) AwardToken(player int) {
+	if len(state.BonusTokens) != state.NumPlayers {
+		state.BonusTokens = make([]int, state.NumPlayers)
+	}
+	state.BonusTokens[player]++
+}
+
+// handValue returns the value of the player's hand at the end of the game, including changes from ValueModifier cards.
+func (state *Gamestate) handValue(player int) int {
+	cards := state.cards()
+	val := cards.Value(state.CardInHand[player])
+	for _, card := range cards.Cards() {
+		if modifier, ok := cards.Definition(card).(ValueModifier); ok {
+			val = modifier.ModifyValue(*state, player, val)
+		}
+	}
+	return val
+}
+
+// knockedOut calls each KnockoutHandler in the player's discards.
+func (state *Gamestate) knockedOut(player int) {
+	cards := state.cards()
+	handled := map[Card]bool{}
+	for _, card := range state.Discards[player] {
+		if handler, ok := cards.Definition(card).(KnockoutHandler); ok && !handled[card] {
+			handled[card] = true
+			handler.KnockedOut(state, player)
+		}
+	}
+}
+
+// awardBonuses adds to BonusTokens from all BonusAwarder cards in the game.
+func (state *Gamestate) awardBonuses() {
+	if len(state.BonusTokens) != state.NumPlayers {
+		state.BonusTokens = make([]int, state.NumPlayers)
+	}
+	cards := state.cards()
+	for _, card := range cards.Cards() {
+		if awarder, ok := cards.Definition(card).(BonusAwarder); ok {
+			for pid, bonus := range awarder.Bonus(*state) {
+				state.BonusTokens[pid] += bonus
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassicDeck(t *testing.T) {
+	assert.Equal(t, Deck{
+		Guard:    5,
+		Priest:   2,
+		Baron:    2,
+		Handmaid: 2,
+		Prince:   2,
+		King:     1,
+		Countess: 1,
+		Princess: 1,
+	}, ClassicCards.Deck())
+	assert.Equal(t, CardNames(), ClassicCards.Cards())
+}
+
+func TestCardSetRegistry(t *testing.T) {
+	cs, err := CardSetNamed("classic")
+	assert.NoError(t, err)
+	assert.Equal(t, ClassicCards, cs)
+
+	_, err = CardSetNamed("no such set")
+	assert.Error(t, err)
+}
+
+// fortuneTeller is a house card that makes every opponent reveal their hand to the active player.
+type fortuneTeller struct{ BaseCard }
+
+func (fortuneTeller) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	for pid := range state.CardInHand {
+		if pid != state.ActivePlayer && !state.EliminatedPlayers[pid] {
+			state.KnownCards[pid][state.ActivePlayer] = state.CardInHand[pid]
+		}
+	}
+}
+
+func newHouseCards() *CardSet {
+	cs := NewCardSet("house", 2, 4)
+	for _, card := range ClassicCards.Cards() {
+		cs.Register(card, ClassicCards.Definition(card))
+	}
+	// Replace the Priest, and make it worth more than a Princess
+	cs.Register(Priest, fortuneTeller{NewBaseCard("Fortune Teller", 9, 2, NoTarget)})
+	return cs
+}
+
+func TestCustomCardEffect(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4}, 3)
+	state.CardSet = newHouseCards()
+
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Countess
+	state.CardInHand[2] = Princess
+	state.ActivePlayerCard = Priest
+
+	assert.Equal(t, []Action{
+		{PlayRecent: true},
+		{PlayRecent: false, TargetPlayerOffset: 1},
+		{PlayRecent: false, TargetPlayerOffset: 2},
+	}, state.LegalActions())
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.Equal(t, Countess, state.KnownCards[1][0])
+	assert.Equal(t, Princess, state.KnownCards[2][0])
+	assert.Equal(t, 1, state.ActivePlayer)
+}
+
+func TestCustomCardValue(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardSet = newHouseCards()
+
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Princess
+	state.ActivePlayerCard = Baron
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 0, state.Winner)
+}
+
+func TestNewGameWithCards(t *testing.T) {
+	cs := newHouseCards()
+	state, err := NewGameWithCards(cs, 2, r)
+	assert.NoError(t, err)
+	assert.Equal(t, cs, state.CardSet)
+	assert.Equal(t, cs, state.Copy().CardSet)
+	assert.Equal(t, "Fortune Teller", cs.Definition(Priest).Name())
+}
+
+func TestTokenRoundTripsEveryCardSet(t *testing.T) {
+	for _, cs := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for i := 0; i < 30; i++ {
+			numPlayers := cs.MinPlayers + i%(cs.MaxPlayers-cs.MinPlayers+1)
+			state, err := NewGameWithCards(cs, numPlayers, r)
+			assert.NoError(t, err)
+
+			game := &Gamestate{}
+			if !assert.NoError(t, game.FromToken(state.Token()), cs.Name) {
+				continue
+			}
+			assert.Equal(t, state.Deck, game.Deck, cs.Name)
+			assert.Equal(t, state.CardInHand, game.CardInHand, cs.Name)
+			assert.Equal(t, state.Faceup, game.Faceup, cs.Name)
+			assert.Equal(t, state.Token(), game.Token(), cs.Name)
+		}
+	}
+}
+
+func TestCardSetToken(t *testing.T) {
+	cs := newHouseCards()
+	RegisterCardSet(cs)
+	state, err := NewGameWithCards(cs, 2, r)
+	assert.NoError(t, err)
+
+	game := &Gamestate{}
+	assert.NoError(t, game.FromToken(state.Token()))
+	assert.Equal(t, cs, game.CardSet)
+	assert.Equal(t, state.Token(), game.Token())
+}
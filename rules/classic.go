@@ -0,0 +1,129 @@
+package rules
+
+import "math/rand"
+
+// ClassicCards is the CardSet for the original Love Letter rules. It is used by default.
+var ClassicCards = newClassicCards()
+
+func init() {
+	RegisterCardSet(ClassicCards)
+}
+
+func newClassicCards() *CardSet {
+	cs := NewCardSet("classic", 2, 4)
+	cs.TokensToWin = classicTokensToWin
+	cs.Register(Guard, guard{NewBaseCard("Guard", 1, 5, GuessOpponent)})
+	cs.Register(Priest, priest{NewBaseCard("Priest", 2, 2, TargetOpponent)})
+	cs.Register(Baron, baron{NewBaseCard("Baron", 3, 2, TargetOpponent)})
+	cs.Register(Handmaid, handmaid{NewBaseCard("Handmaid", 4, 2, NoTarget)})
+	cs.Register(Prince, prince{NewBaseCard("Prince", 5, 2, TargetAnyPlayer)})
+	cs.Register(King, king{NewBaseCard("King", 6, 1, TargetOpponent)})
+	cs.Register(Countess, countess{NewBaseCard("Countess", 7, 1, NoTarget)})
+	cs.Register(Princess, princess{NewBaseCard("Princess", 8, 1, NoTarget)})
+	return cs
+}
+
+type guard struct{ BaseCard }
+
+func (guard) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	targetCard := state.CardInHand[target]
+	correct := targetCard == action.SelectedCard && targetCard != Guard
+	state.RecordEvent(GuardGuess{Player: state.ActivePlayer, Target: target, Guess: action.SelectedCard, Correct: correct})
+	if correct {
+		state.eliminatePlayer(target, EliminatedByGuess)
+	}
+	// Note we don't store this history, which a real player would rely upon. e.g. if I guess 4 and it's wrong, do I guess 4 again the next turn when no Handmaids have shown up? This bot would do that.
+}
+
+type priest struct{ BaseCard }
+
+func (priest) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	state.reveal(target, state.ActivePlayer)
+}
+
+type baron struct{ BaseCard }
+
+func (baron) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	// Compare cards. Eliminate low. Tie does nothing
+	targetValue, activeValue := state.compareHands(target)
+	switch {
+	case targetValue < activeValue:
+		state.eliminatePlayer(target, EliminatedByComparison)
+	case targetValue > activeValue:
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByComparison)
+	}
+}
+
+// compareHands records the active player comparing hands with the target, and returns the value of each hand.
+func (state *Gamestate) compareHands(target int) (targetValue, activeValue int) {
+	cards := state.cards()
+	state.RecordEvent(BaronCompare{
+		Player:     state.ActivePlayer,
+		Target:     target,
+		PlayerCard: state.CardInHand[state.ActivePlayer],
+		TargetCard: state.CardInHand[target],
+	})
+	return cards.Value(state.CardInHand[target]), cards.Value(state.CardInHand[state.ActivePlayer])
+}
+
+// reveal shows the player's card to the viewer.
+func (state *Gamestate) reveal(player, viewer int) {
+	state.RecordEvent(PriestReveal{Player: viewer, Target: player, Card: state.CardInHand[player]})
+	state.KnownCards[player][viewer] = state.CardInHand[player]
+}
+
+type handmaid struct{ BaseCard }
+
+func (handmaid) Protects() bool { return true }
+
+type prince struct{ BaseCard }
+
+func (prince) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	state.ForceDiscard(target, r)
+	if target == state.ActivePlayer && state.EliminatedPlayers[target] {
+		// This was stupid UNLESS every other player has a Handmaid, in which case this is okay.
+		if len(state.targetableOffsets()) > 0 {
+			state.LossWasStupid = true
+		}
+	}
+}
+
+type king struct{ BaseCard }
+
+func (king) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	state.swapHands(state.ActivePlayer, target)
+}
+
+// swapHands trades the cards in the hands of two different players, and updates what each of them knows.
+func (state *Gamestate) swapHands(a, b int) {
+	state.RecordEvent(KingSwap{Player: a, Target: b})
+	cardA := state.CardInHand[a]
+	cardB := state.CardInHand[b]
+	state.CardInHand[a] = cardB
+	state.CardInHand[b] = cardA
+	// Update knowledge
+	for i := range state.KnownCards[a] {
+		if cardA == state.KnownCards[a][i] {
+			state.KnownCards[a][i] = cardB
+		}
+		if cardB == state.KnownCards[b][i] {
+			state.KnownCards[b][i] = cardA
+		}
+	}
+	state.KnownCards[a][b] = cardB
+	state.KnownCards[b][a] = cardA
+}
+
+type countess struct{ BaseCard }
+
+func (countess) MustBePlayedInsteadOf(card Card) bool { return card == King || card == Prince }
+
+type princess struct{ BaseCard }
+
+func (princess) EliminatesWhenDiscarded() bool { return true }
+
+func (princess) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	// Idiot!
+	state.eliminatePlayer(state.ActivePlayer, EliminatedByDiscard)
+	state.LossWasStupid = true
+}
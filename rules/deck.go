@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"fmt"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -23,31 +24,37 @@ func (st Stack) Strings() []string {
 	return strs
 }
 
+// Token writes each card as one base 36 digit, so the classic cards are their values and later cards are letters.
 func (st Stack) Token() string {
 	str := "["
 	for _, c := range st {
-		str += strconv.Itoa(int(c))
+		str += strconv.FormatInt(int64(c), 36)
 	}
 	str += "]"
 	return str
 }
 
-func (st *Stack) FromToken(str string) {
+// FromToken reads a stack written by Token. The stack is empty if there's an error.
+func (st *Stack) FromToken(str string) error {
+	*st = Stack{}
 	length := len(str)
-	if length <= 2 || str[0] != '[' || str[length-1] != ']' {
-		*st = Stack{}
-		return // We don't do errors 'round here
+	if length < 2 || str[0] != '[' || str[length-1] != ']' {
+		return fmt.Errorf("The stack '%s' is not in brackets", str)
 	}
-	str = str[1 : length-1]
-	length -= 2
-	cards := []Card{}
-	for _, c := range str {
-		cards = append(cards, Card(unsafeAtoi(string(c))))
+	cards := Stack{}
+	for _, c := range str[1 : length-1] {
+		card, err := strconv.ParseInt(string(c), 36, 0)
+		if err != nil || card >= int64(numberOfCards) {
+			return fmt.Errorf("The stack '%s' contains '%c', which isn't a card", str, c)
+		}
+		cards = append(cards, Card(card))
 	}
 	*st = cards
+	return nil
 }
 
-// For now, the Card value is its face value.
+// A Card identifies a type of card. For the classic cards, the Card value is its face value.
+// Other cards' values depend on the CardSet.
 const (
 	None = Card(iota) // Used to indicate errors or other things
 	Guard
@@ -58,18 +65,40 @@ const (
 	King
 	Countess
 	Princess
+	Spy          // 2019 edition
+	Chancellor   // 2019 edition
+	Jester       // Premium edition
+	Assassin     // Premium edition
+	Cardinal     // Premium edition
+	Baroness     // Premium edition
+	Sycophant    // Premium edition
+	Count        // Premium edition
+	Constable    // Premium edition
+	DowagerQueen // Premium edition
+	Bishop       // Premium edition
 	numberOfCards
 )
 
 var nameOfCard = map[Card]string{
-	Guard:    "Guard",
-	Priest:   "Priest",
-	Baron:    "Baron",
-	Handmaid: "Handmaid",
-	Prince:   "Prince",
-	King:     "King",
-	Countess: "Countess",
-	Princess: "Princess",
+	Guard:        "Guard",
+	Priest:       "Priest",
+	Baron:        "Baron",
+	Handmaid:     "Handmaid",
+	Prince:       "Prince",
+	King:         "King",
+	Countess:     "Countess",
+	Princess:     "Princess",
+	Spy:          "Spy",
+	Chancellor:   "Chancellor",
+	Jester:       "Jester",
+	Assassin:     "Assassin",
+	Cardinal:     "Cardinal",
+	Baroness:     "Baroness",
+	Sycophant:    "Sycophant",
+	Count:        "Count",
+	Constable:    "Constable",
+	DowagerQueen: "Dowager Queen",
+	Bishop:       "Bishop",
 }
 
 func (c Card) String() string {
@@ -89,17 +118,9 @@ func CardFromString(s string) Card {
 // Deck contains counts of Cards
 type Deck [numberOfCards]int
 
+// DefaultDeck returns the deck for ClassicCards.
 func DefaultDeck() Deck {
-	return Deck{
-		Guard:    5,
-		Priest:   2,
-		Baron:    2,
-		Handmaid: 2,
-		Prince:   2,
-		King:     1,
-		Countess: 1,
-		Princess: 1,
-	}
+	return ClassicCards.Deck()
 }
 
 func CardNames() []Card {
@@ -148,6 +169,17 @@ func (deck *Deck) Draw(r *rand.Rand) Card {
 	return None
 }
 
+// AsStack returns every card in the deck, in order.
+func (deck Deck) AsStack() Stack {
+	stack := Stack{}
+	for card, count := range deck {
+		for i := 0; i < count; i++ {
+			stack = append(stack, Card(card))
+		}
+	}
+	return stack
+}
+
 func (deck *Deck) AddStack(stack Stack) {
 	for _, card := range stack {
 		deck[card] += 1
@@ -216,10 +248,11 @@ func (sts Stacks) Token() string {
 	return "[" + strings.Join(strs, "-") + "]"
 }
 
-func (sts *Stacks) FromToken(str string) {
+// FromToken reads stacks written by Token. The stacks are unchanged if there's an error.
+func (sts *Stacks) FromToken(str string) error {
 	length := len(str)
 	if length <= 2 || str[0] != '[' || str[length-1] != ']' {
-		return // We don't do errors 'round here
+		return fmt.Errorf("The stacks '%s' are not in brackets", str)
 	}
 
 	strs := strings.Split(str[1:length-1], "-")
@@ -227,8 +260,11 @@ func (sts *Stacks) FromToken(str string) {
 	stacks := []Stack{}
 	for _, c := range strs {
 		stk := Stack{}
-		stk.FromToken(c)
+		if err := stk.FromToken(c); err != nil {
+			return err
+		}
 		stacks = append(stacks, stk)
 	}
 	*sts = stacks
+	return nil
 }
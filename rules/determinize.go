@@ -0,0 +1,207 @@
+package rules
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// maxSampleAttempts is the number of times a Sampler tries to find a deal consistent with failed guesses.
+const maxSampleAttempts = 1000
+
+// Sampler generates full game states that are consistent with everything one player knows about a game.
+// Every unseen card is equally likely to be in any hidden place (an opponent's hand, the deck, or the face-down card),
+// except where the player knows better: cards they have seen with a Priest or King, cards they put on the bottom of the
+// deck (e.g. with a Chancellor), and cards an opponent can't hold because a Guard guessed them wrong.
+type Sampler struct {
+	state  Gamestate
+	player int
+
+	// pool contains every card the player hasn't seen.
+	pool Stack
+
+	// slots are the hidden cards that need to be sampled from the pool. The rest of the pool is the deck.
+	slots []hiddenSlot
+}
+
+// hiddenSlot is a card that's hidden from the player.
+type hiddenSlot struct {
+	// set puts the sampled card into the state.
+	set func(state *Gamestate, card Card)
+
+	// excluded contains the cards that can't be here.
+	excluded map[Card]bool
+}
+
+// NewSampler returns a Sampler for what the player knows about the game.
+func NewSampler(state Gamestate, player int) (*Sampler, error) {
+	if player < 0 || player >= state.NumPlayers {
+		return nil, errors.New("The player isn't in the game")
+	}
+	sampler := &Sampler{state: state.Copy(), player: player}
+	sampler.state.EventLog = state.EventLog.VisibleTo(player)
+
+	// Start from the full deck, and remove everything the player has seen
+	unseen := state.cards().Deck()
+	seen := state.Faceup.Copy()
+	for _, discards := range state.Discards {
+		seen = append(seen, discards...)
+	}
+	seen = append(seen, state.CardInHand[player])
+	isActive := player == state.ActivePlayer && !state.GameEnded
+	if isActive {
+		seen = append(seen, state.PendingDraws...)
+		if state.PendingCard == None {
+			seen = append(seen, state.ActivePlayerCard)
+		}
+	}
+
+	excluded := state.guessExclusions(player)
+	for pid := range state.CardInHand {
+		if pid == player || state.EliminatedPlayers[pid] {
+			continue
+		}
+		if known := state.KnownCards[pid][player]; known != None {
+			seen = append(seen, known)
+			continue
+		}
+		pid := pid
+		sampler.slots = append(sampler.slots, hiddenSlot{
+			set:      func(state *Gamestate, card Card) { state.CardInHand[pid] = card },
+			excluded: excluded[pid],
+		})
+	}
+	if !isActive && !state.GameEnded {
+		if state.PendingCard == None {
+			sampler.slots = append(sampler.slots, hiddenSlot{
+				set: func(state *Gamestate, card Card) { state.ActivePlayerCard = card },
+			})
+		}
+		for i := range state.PendingDraws {
+			i := i
+			sampler.slots = append(sampler.slots, hiddenSlot{
+				set: func(state *Gamestate, card Card) { state.PendingDraws[i] = card },
+			})
+		}
+	}
+	for i, card := range state.DeckBottom {
+		if state.bottomPlayer(i) == player {
+			// The player knows the cards they put there, and their order
+			seen = append(seen, card)
+			continue
+		}
+		i := i
+		sampler.slots = append(sampler.slots, hiddenSlot{
+			set: func(state *Gamestate, card Card) { state.DeckBottom[i] = card },
+		})
+	}
+
+	for _, card := range seen {
+		if card == None {
+			continue
+		}
+		if unseen[card] == 0 {
+			return nil, errors.New("The player has seen more copies of a " + card.String() + " than are in the deck")
+		}
+		unseen[card]--
+	}
+	for card, count := range unseen {
+		for i := 0; i < count; i++ {
+			sampler.pool = append(sampler.pool, Card(card))
+		}
+	}
+	if len(sampler.pool) != len(sampler.slots)+state.Deck.Size() {
+		return nil, errors.New("The number of unseen cards doesn't match the hidden cards")
+	}
+	return sampler, nil
+}
+
+// Sample returns a full game state consistent with what the player knows. The player's own knowledge, and the
+// knowledge other players are publicly known to have (e.g. from a Priest), is updated to match the sampled cards.
+// The EventLog only contains what the player can see.
+func (sampler *Sampler) Sample(r *rand.Rand) (Gamestate, error) {
+	order := sampler.pool.Copy()
+	for attempt := 0; attempt < maxSampleAttempts; attempt++ {
+		r.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		if !sampler.isConsistent(order) {
+			continue
+		}
+
+		state := sampler.state.Copy()
+		for i, slot := range sampler.slots {
+			slot.set(&state, order[i])
+		}
+		state.Deck = Deck{}
+		state.Deck.AddStack(order[len(sampler.slots):])
+
+		// Other players still know the cards they've seen, but those cards may have changed
+		for about, knowers := range state.KnownCards {
+			for knower, known := range knowers {
+				if known != None && knower != sampler.player {
+					knowers[knower] = state.CardInHand[about]
+				}
+			}
+		}
+		return state, nil
+	}
+	return Gamestate{}, errors.New("No deal consistent with the failed guesses was found")
+}
+
+// isConsistent returns true if no slot gets a card it excludes.
+func (sampler *Sampler) isConsistent(order Stack) bool {
+	for i, slot := range sampler.slots {
+		if slot.excluded[order[i]] {
+			return false
+		}
+	}
+	return true
+}
+
+// Determinize returns a copy of the game in which everything hidden from the player is sampled from what they know.
+// To sample many times, use a Sampler.
+func (state Gamestate) Determinize(player int, r *rand.Rand) (Gamestate, error) {
+	sampler, err := NewSampler(state, player)
+	if err != nil {
+		return Gamestate{}, err
+	}
+	return sampler.Sample(r)
+}
+
+// guessExclusions returns the cards each player can't be holding, because a guess (e.g. with a Guard) the player saw
+// was wrong and the guessed player hasn't changed their card since. Cards that implement Guesser guess by value, so
+// every card with the guessed value is excluded.
+func (state Gamestate) guessExclusions(player int) []map[Card]bool {
+	cards := state.cards()
+	excluded := make([]map[Card]bool, state.NumPlayers)
+	lastPlayed := None
+	for _, event := range state.EventLog.VisibleTo(player).Events {
+		switch e := event.(type) {
+		case CardPlayed:
+			// The player might have kept the card they drew, so the guess no longer applies
+			lastPlayed = e.Card
+			excluded[e.Player] = nil
+		case GuardGuess:
+			if e.Correct {
+				continue
+			}
+			if excluded[e.Target] == nil {
+				excluded[e.Target] = map[Card]bool{}
+			}
+			if _, ok := cards.Definition(lastPlayed).(Guesser); ok {
+				for _, card := range cards.Cards() {
+					if cards.Value(card) == cards.Value(e.Guess) {
+						excluded[e.Target][card] = true
+					}
+				}
+			} else {
+				excluded[e.Target][e.Guess] = true
+			}
+		case PrinceDiscard:
+			excluded[e.Player] = nil
+		case KingSwap:
+			excluded[e.Player], excluded[e.Target] = excluded[e.Target], excluded[e.Player]
+		case Eliminated:
+			excluded[e.Player] = nil
+		}
+	}
+	return excluded
+}
@@ -0,0 +1,138 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterminizeKeepsWhatPlayerKnows(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		state, _ := NewGame(4, r)
+		for turn := 0; turn < 3 && !state.GameEnded; turn++ {
+			acts := state.LegalActions()
+			state.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+		if state.GameEnded {
+			continue
+		}
+		player := (state.ActivePlayer + 1) % 4
+
+		sampled, err := state.Determinize(player, r)
+		assert.NoError(t, err)
+		assert.Equal(t, state.CardInHand[player], sampled.CardInHand[player])
+		assert.Equal(t, state.Copy().Discards, sampled.Discards)
+		assert.Equal(t, state.Deck.Size(), sampled.Deck.Size())
+		assert.Equal(t, state.EventLog.VisibleTo(player), sampled.EventLog)
+		for pid, known := range state.KnownCards {
+			if known[player] != None {
+				assert.Equal(t, state.CardInHand[pid], sampled.CardInHand[pid], "Known cards aren't resampled")
+			}
+		}
+
+		// Every card is still accounted for
+		cards := sampled.AllDiscards()
+		cards.AddStack(sampled.CardInHand)
+		cards.AddStack(Stack{sampled.ActivePlayerCard})
+		for i := range cards {
+			cards[i] += sampled.Deck[i]
+		}
+		cards[None] = 0
+		assert.Equal(t, DefaultDeck(), cards)
+	}
+}
+
+func TestSamplerProbabilities(t *testing.T) {
+	state := newGame(Deck{Guard: 2, Baron: 2}, 2)
+	state.CardSet = newTinyCards()
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Guard
+	state.ActivePlayerCard = Priest
+	// The unseen cards are 3 Guards and 2 Barons
+
+	sampler, err := NewSampler(state, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	guards := 0
+	for i := 0; i < 5000; i++ {
+		sampled, err := sampler.Sample(r)
+		assert.NoError(t, err)
+		if sampled.CardInHand[1] == Guard {
+			guards++
+		}
+	}
+	assert.InDelta(t, 3.0/5.0, float64(guards)/5000, 0.03)
+}
+
+func TestSamplerExcludesFailedGuesses(t *testing.T) {
+	state := newGame(Deck{Guard: 2, Priest: 2}, 2)
+	state.CardSet = newTinyCards()
+	state.EventLog = newEventLog(2)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Baron
+	state.ActivePlayerCard = Guard
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Priest}, r)
+	assert.False(t, state.EliminatedPlayers[1])
+
+	// Player 1 is now active, so sample from player 0's point of view
+	sampler, err := NewSampler(state, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 0; i < 100; i++ {
+		sampled, err := sampler.Sample(r)
+		assert.NoError(t, err)
+		assert.NotEqual(t, Priest, sampled.CardInHand[1], "The guess was wrong")
+	}
+
+	// Once player 1 plays a card, they might hold the card they drew instead
+	state.PlayCard(Action{PlayRecent: state.ActivePlayerCard != Baron, TargetPlayerOffset: 1, SelectedCard: Priest}, r)
+	assert.Empty(t, state.guessExclusions(0)[1])
+}
+
+// newTinyCards returns a CardSet with just a few cards, so sampling probabilities are easy to calculate.
+func newTinyCards() *CardSet {
+	cs := NewCardSet("tiny", 2, 2)
+	cs.Register(Guard, guard{NewBaseCard("Guard", 1, 3, GuessOpponent)})
+	cs.Register(Priest, priest{NewBaseCard("Priest", 2, 2, TargetOpponent)})
+	cs.Register(Baron, baron{NewBaseCard("Baron", 3, 2, TargetOpponent)})
+	return cs
+}
+
+func TestSamplerKeepsCardsPlayerPutOnBottom(t *testing.T) {
+	found, changed := 0, false
+	for i := 0; i < 500 && found < 20; i++ {
+		state, _ := NewGameWithCards(Edition2019Cards, 2, r)
+		for !state.GameEnded && len(state.DeckBottom) == 0 {
+			acts := state.LegalActions()
+			state.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+		if state.GameEnded {
+			continue
+		}
+		found++
+		placer := state.DeckBottomPlayers[0]
+		assert.Len(t, state.DeckBottomPlayers, len(state.DeckBottom))
+
+		sampler, err := NewSampler(state, placer)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		other, err := NewSampler(state, 1-placer)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		for j := 0; j < 50; j++ {
+			sampled, err := sampler.Sample(r)
+			assert.NoError(t, err)
+			assert.Equal(t, state.DeckBottom, sampled.DeckBottom, "The player knows the cards they put on the bottom")
+
+			sampled, err = other.Sample(r)
+			assert.NoError(t, err)
+			changed = changed || !assert.ObjectsAreEqual(state.DeckBottom, sampled.DeckBottom)
+		}
+	}
+	assert.NotZero(t, found)
+	assert.True(t, changed, "The other player doesn't know the cards on the bottom")
+}
@@ -0,0 +1,127 @@
+package rules
+
+import "math/rand"
+
+// Edition2019Cards is the CardSet for the 2019 edition of Love Letter, which adds the Spy and Chancellor and changes
+// the values of the King, Countess, and Princess.
+var Edition2019Cards = newEdition2019Cards()
+
+func init() {
+	RegisterCardSet(Edition2019Cards)
+}
+
+func newEdition2019Cards() *CardSet {
+	cs := NewCardSet("2019", 2, 6)
+	cs.TokensToWin = map[int]int{2: 6, 3: 5, 4: 4, 5: 3, 6: 3}
+	cs.Register(Spy, spy{NewBaseCard("Spy", 0, 2, NoTarget)})
+	cs.Register(Guard, guard{NewBaseCard("Guard", 1, 6, GuessOpponent)})
+	cs.Register(Priest, priest{NewBaseCard("Priest", 2, 2, TargetOpponent)})
+	cs.Register(Baron, baron{NewBaseCard("Baron", 3, 2, TargetOpponent)})
+	cs.Register(Handmaid, handmaid{NewBaseCard("Handmaid", 4, 2, NoTarget)})
+	cs.Register(Prince, prince{NewBaseCard("Prince", 5, 2, TargetAnyPlayer)})
+	cs.Register(Chancellor, chancellor{NewBaseCard("Chancellor", 6, 2, NoTarget)})
+	cs.Register(King, king{NewBaseCard("King", 7, 1, TargetOpponent)})
+	cs.Register(Countess, countess{NewBaseCard("Countess", 8, 1, NoTarget)})
+	cs.Register(Princess, princess{NewBaseCard("Princess", 9, 1, NoTarget)})
+	return cs
+}
+
+type spy struct{ BaseCard }
+
+// Bonus awards a token to the only player still in the game who played or discarded a Spy (if there is exactly one).
+func (spy) Bonus(state Gamestate) []int {
+	bonus := make([]int, state.NumPlayers)
+	spyPlayer := -1
+	for pid, discards := range state.Discards {
+		if state.EliminatedPlayers[pid] {
+			continue
+		}
+		for _, card := range discards {
+			if card == Spy {
+				if spyPlayer >= 0 {
+					// More than one player has a Spy, so no one gets the bonus
+					return bonus
+				}
+				spyPlayer = pid
+				break
+			}
+		}
+	}
+	if spyPlayer >= 0 {
+		bonus[spyPlayer] = 1
+	}
+	return bonus
+}
+
+type chancellor struct{ BaseCard }
+
+// Play draws up to two cards, which the active player must then choose between with a second action.
+func (chancellor) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	draws := state.DrawPileSize()
+	if draws > 2 {
+		draws = 2
+	}
+	if draws == 0 {
+		// There's nothing to draw, so the Chancellor has no effect
+		return
+	}
+	state.PendingDraws = make(Stack, 0, draws)
+	for i := 0; i < draws; i++ {
+		state.PendingDraws = append(state.PendingDraws, state.Draw(r))
+	}
+	state.PendingCard = Chancellor
+}
+
+// Resolutions returns an action for each card that can be kept, combined with each card that can go on the bottom of the deck.
+func (chancellor) Resolutions(state Gamestate) []Action {
+	hand := append(Stack{state.CardInHand[state.ActivePlayer]}, state.PendingDraws...)
+	acts := []Action{}
+	seen := map[Action]bool{}
+	for i, keep := range hand {
+		returned := append(hand[:i:i], hand[i+1:]...)
+		for _, bottom := range returned {
+			act := Action{KeepCard: keep}
+			if len(returned) > 1 {
+				act.BottomCard = bottom
+			}
+			if !seen[act] {
+				seen[act] = true
+				acts = append(acts, act)
+			}
+		}
+	}
+	return acts
+}
+
+// Resolve keeps the selected card and puts the rest on the bottom of the deck, with BottomCard last.
+func (chancellor) Resolve(state *Gamestate, action Action, r *rand.Rand) {
+	old := state.CardInHand[state.ActivePlayer]
+	hand := append(Stack{old}, state.PendingDraws...)
+	hand = hand.without(action.KeepCard)
+	if action.BottomCard != None {
+		hand = append(hand.without(action.BottomCard), action.BottomCard)
+	}
+	state.CardInHand[state.ActivePlayer] = action.KeepCard
+	state.putOnBottom(state.ActivePlayer, hand)
+
+	if action.KeepCard != old {
+		// No one knows this player's card anymore
+		for i := range state.KnownCards[state.ActivePlayer] {
+			state.KnownCards[state.ActivePlayer][i] = None
+		}
+	}
+}
+
+// without returns a copy of the stack with the first copy of the card removed.
+func (stack Stack) without(card Card) Stack {
+	result := make(Stack, 0, len(stack))
+	removed := false
+	for _, val := range stack {
+		if val == card && !removed {
+			removed = true
+			continue
+		}
+		result = append(result, val)
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newEdition2019Game(deck Deck, playerCount int) Gamestate {
+	state := newGame(deck, playerCount)
+	state.CardSet = Edition2019Cards
+	return state
+}
+
+func TestEdition2019Deck(t *testing.T) {
+	deck := Edition2019Cards.Deck()
+	assert.Equal(t, 21, deck.Size())
+	assert.Equal(t, 2, deck[Spy])
+	assert.Equal(t, 6, deck[Guard])
+	assert.Equal(t, 2, deck[Chancellor])
+	assert.Equal(t, 9, Edition2019Cards.Value(Princess))
+	assert.Equal(t, 0, Edition2019Cards.Value(Spy))
+}
+
+func TestEdition2019NewGame(t *testing.T) {
+	state, err := NewGameWithCards(Edition2019Cards, 2, r)
+	assert.NoError(t, err)
+	assert.Equal(t, 21-3-3, state.Deck.Size())
+}
+
+func TestChancellorKeepsAndReturns(t *testing.T) {
+	r.Seed(0)
+	state := newEdition2019Game(Deck{Guard: 1, Priest: 1, Baron: 1}, 2)
+	state.CardInHand[0] = Spy
+	state.CardInHand[1] = Princess
+	state.KnownCards[0][1] = Spy
+	state.ActivePlayerCard = Chancellor
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.Equal(t, Chancellor, state.PendingCard)
+	assert.Len(t, state.PendingDraws, 2)
+	assert.Equal(t, 0, state.ActivePlayer, "The same player must resolve the Chancellor")
+	assert.Equal(t, 1, state.Deck.Size())
+
+	acts := state.LegalActions()
+	assert.Len(t, acts, 6)
+	keep := state.PendingDraws[0]
+	other := state.PendingDraws[1]
+	assert.Contains(t, acts, Action{KeepCard: keep, BottomCard: Spy})
+
+	assert.Error(t, state.CheckAction(Action{KeepCard: Princess}))
+	assert.NoError(t, state.TryPlayCard(Action{KeepCard: keep, BottomCard: Spy}, r))
+
+	assert.Equal(t, None, state.PendingCard)
+	assert.Equal(t, keep, state.CardInHand[0])
+	assert.Equal(t, None, state.KnownCards[0][1], "Player 1 no longer knows player 0's card")
+	assert.Equal(t, 1, state.ActivePlayer)
+	assert.Equal(t, other, state.ActivePlayerCard, "The next draw comes from the bottom of the deck")
+	assert.Equal(t, Stack{Spy}, state.DeckBottom)
+}
+
+func TestChancellorWithOneCardLeft(t *testing.T) {
+	r.Seed(0)
+	state := newEdition2019Game(Deck{Guard: 1, Priest: 1}, 2)
+	state.CardInHand[0] = Spy
+	state.CardInHand[1] = Princess
+	state.ActivePlayerCard = Chancellor
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.Len(t, state.PendingDraws, 1)
+	drawn := state.PendingDraws[0]
+	assert.Equal(t, []Action{{KeepCard: Spy}, {KeepCard: drawn}}, state.LegalActions())
+
+	state.PlayCard(Action{KeepCard: drawn}, r)
+	assert.Equal(t, drawn, state.CardInHand[0])
+	assert.Equal(t, Spy, state.ActivePlayerCard)
+	assert.False(t, state.GameEnded)
+}
+
+func TestChancellorWithEmptyDeck(t *testing.T) {
+	r.Seed(0)
+	state := newEdition2019Game(Deck{Guard: 1}, 2)
+	state.CardInHand[0] = Spy
+	state.CardInHand[1] = Princess
+	state.ActivePlayerCard = Chancellor
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.Equal(t, None, state.PendingCard)
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 1, state.Winner)
+}
+
+func TestEdition2019Values(t *testing.T) {
+	r.Seed(0)
+	state := newEdition2019Game(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = King
+	state.CardInHand[1] = Chancellor
+	state.ActivePlayerCard = Baron
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 0, state.Winner, "The King is worth more than the Chancellor")
+}
+
+func TestSpyBonus(t *testing.T) {
+	r.Seed(0)
+	state := newEdition2019Game(Deck{Guard: 1}, 3)
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Princess
+	state.CardInHand[2] = Guard
+	state.Discards[1] = Stack{Spy}
+	state.Discards[2] = Stack{Spy}
+	state.EliminatedPlayers[2] = true
+	state.CardInHand[2] = None
+	state.ActivePlayerCard = Handmaid
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 1, state.Winner)
+	assert.Equal(t, []int{0, 1, 0}, state.BonusTokens, "Only player 1 is still in the game with a Spy")
+}
+
+func TestSpyBonusShared(t *testing.T) {
+	r.Seed(0)
+	state := newEdition2019Game(Deck{Guard: 1}, 2)
+	state.CardInHand[0] = Spy
+	state.CardInHand[1] = Princess
+	state.Discards[1] = Stack{Spy}
+	state.ActivePlayerCard = Guard
+
+	state.PlayCard(Action{PlayRecent: false}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, []int{0, 0}, state.BonusTokens, "Both players have a Spy, so neither gets the bonus")
+}
+
+func TestEdition2019GamesComplete(t *testing.T) {
+	for _, numPlayers := range []int{2, 3, 4} {
+		for i := 0; i < 100; i++ {
+			state, err := NewGameWithCards(Edition2019Cards, numPlayers, r)
+			assert.NoError(t, err)
+			for !state.GameEnded {
+				acts := state.LegalActions()
+				assert.NoError(t, state.TryPlayCard(acts[r.Intn(len(acts))], r))
+			}
+
+			cards := state.AllDiscards()
+			cards.AddStack(state.CardInHand)
+			cards.AddStack(state.DeckBottom)
+			for i := range cards {
+				cards[i] += state.Deck[i]
+			}
+			cards[None] = 0
+			assert.Equal(t, Edition2019Cards.Deck(), cards)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Spectator is the viewer id of someone who isn't playing, who can only see public events.
+const Spectator = -1
+
+// Event is something that happened in a game.
+type Event interface {
+	// Text describes the event, using the provided names for players.
+	Text(names []string) string
+
+	// VisibleTo returns true if the player (or Spectator) is entitled to see the event.
+	VisibleTo(viewer int) bool
+}
+
+type EventLog struct {
+	// PlayerNames is a slice of player names.
+	PlayerNames []string
+
+	// Events contains everything that happened in the game, in order.
+	Events []Event
+}
+
+func newEventLog(playerCount int) EventLog {
+	var el EventLog
+	el.record(GameStarted{NumPlayers: playerCount})
+	el.PlayerNames = make([]string, playerCount)
+	for i := 0; i < playerCount; i++ {
+		el.PlayerNames[i] = fmt.Sprintf("Player %d", i)
+	}
+	return el
+}
+
+func (eventlog EventLog) Copy() EventLog {
+	el := EventLog{}
+
+	el.PlayerNames = make([]string, 0, len(eventlog.PlayerNames))
+	for _, val := range eventlog.PlayerNames {
+		el.PlayerNames = append(el.PlayerNames, val)
+	}
+
+	// Events are never modified, so they can be shared
+	el.Events = make([]Event, 0, len(eventlog.Events))
+	for _, val := range eventlog.Events {
+		el.Events = append(el.Events, val)
+	}
+
+	return el
+}
+
+// VisibleTo returns a copy of the log with only the events the viewer (a player id or Spectator) is entitled to see.
+func (eventlog EventLog) VisibleTo(viewer int) EventLog {
+	el := eventlog.Copy()
+	el.Events = el.Events[:0]
+	for _, event := range eventlog.Events {
+		if event.VisibleTo(viewer) {
+			el.Events = append(el.Events, event)
+		}
+	}
+	return el
+}
+
+// Lines returns a human-readable line for each event, in order.
+func (eventlog EventLog) Lines() []string {
+	lines := make([]string, len(eventlog.Events))
+	for i, event := range eventlog.Events {
+		lines[i] = event.Text(eventlog.PlayerNames)
+	}
+	return lines
+}
+
+func (eventlog EventLog) String() string {
+	return strings.Join(eventlog.Lines(), "\n")
+}
+
+func (el *EventLog) record(event Event) {
+	el.Events = append(el.Events, event)
+}
+
+// RecordEvent adds the event to the game's EventLog. It's intended to be used by card effects.
+func (state *Gamestate) RecordEvent(event Event) {
+	state.EventLog.record(event)
+}
+
+// eventTypes contains every type of event that can be saved, by name.
+var eventTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, event := range []Event{GameStarted{}, CardPlayed{}, GuardGuess{}, PriestReveal{}, BaronCompare{}, PrinceDiscard{}, KingSwap{}, Eliminated{}, RoundEnd{}} {
+		RegisterEvent(event)
+	}
+}
+
+// RegisterEvent makes a type of event (e.g. one recorded by a custom card) available when saving and loading games.
+// The type is registered by its name, and must be a struct that can be encoded as JSON.
+func RegisterEvent(event Event) {
+	eventTypes[eventName(event)] = reflect.TypeOf(event)
+}
+
+func eventName(event Event) string {
+	return reflect.TypeOf(event).Name()
+}
+
+// loadEvent decodes a saved event of a registered type.
+func loadEvent(saved savedEvent) (Event, error) {
+	t, ok := eventTypes[saved.Type]
+	if !ok {
+		return nil, fmt.Errorf("The event type %s isn't registered", saved.Type)
+	}
+	event := reflect.New(t)
+	decoder := json.NewDecoder(bytes.NewReader(saved.Event))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(event.Interface()); err != nil {
+		return nil, fmt.Errorf("The %s event is not valid: %v", saved.Type, err)
+	}
+	return event.Elem().Interface().(Event), nil
+}
+
+// nameOf returns the name of the player, or a default name if it's unknown.
+func nameOf(names []string, player int) string {
+	if player >= 0 && player < len(names) && names[player] != "" {
+		return names[player]
+	}
+	return fmt.Sprintf("Player %d", player)
+}
+
+// GameStarted is recorded when a game is dealt, or loaded from a Token.
+type GameStarted struct {
+	NumPlayers int
+
+	// Token is the token the game was loaded from, if any.
+	Token string
+}
+
+func (e GameStarted) Text(names []string) string {
+	if e.Token != "" {
+		return "Game was created from token " + e.Token
+	}
+	return fmt.Sprintf("New game with %d players", e.NumPlayers)
+}
+
+func (GameStarted) VisibleTo(viewer int) bool { return true }
+
+// CardPlayed is recorded when a player plays a card.
+type CardPlayed struct {
+	Player int
+	Card   Card
+
+	// Target and SecondTarget are the ids of the targeted players, or -1 if there is no target.
+	Target, SecondTarget int
+
+	// Blocked is true if the card had no effect because its target was protected.
+	Blocked bool
+}
+
+func (e CardPlayed) Text(names []string) string {
+	str := nameOf(names, e.Player) + " played a " + e.Card.String()
+	if e.Target >= 0 && e.Target != e.Player {
+		str += " on " + nameOf(names, e.Target)
+	} else if e.Target == e.Player {
+		str += " on themselves"
+	}
+	if e.SecondTarget >= 0 {
+		str += " and " + nameOf(names, e.SecondTarget)
+	}
+	if e.Blocked {
+		str += ", but it was blocked by a Handmaid"
+	}
+	return str
+}
+
+func (CardPlayed) VisibleTo(viewer int) bool { return true }
+
+// GuardGuess is recorded when a player guesses another player's card (e.g. with a Guard).
+type GuardGuess struct {
+	Player, Target int
+	Guess          Card
+	Correct        bool
+}
+
+func (e GuardGuess) Text(names []string) string {
+	result := "wrong"
+	if e.Correct {
+		result = "correct"
+	}
+	return fmt.Sprintf("%s guessed that %s had a %s, which was %s", nameOf(names, e.Player), nameOf(names, e.Target), e.Guess, result)
+}
+
+func (GuardGuess) VisibleTo(viewer int) bool { return true }
+
+// PriestReveal is recorded when a player looks at another player's card (e.g. with a Priest).
+// Only the player who looked can see it.
+type PriestReveal struct {
+	Player, Target int
+	Card           Card
+}
+
+func (e PriestReveal) Text(names []string) string {
+	return fmt.Sprintf("%s saw that %s has a %s", nameOf(names, e.Player), nameOf(names, e.Target), e.Card)
+}
+
+func (e PriestReveal) VisibleTo(viewer int) bool { return viewer == e.Player }
+
+// BaronCompare is recorded when two players compare their cards (e.g. with a Baron).
+// Only the two players can see it.
+type BaronCompare struct {
+	Player, Target         int
+	PlayerCard, TargetCard Card
+}
+
+func (e BaronCompare) Text(names []string) string {
+	return fmt.Sprintf("%s revealed a %s against a %s from %s", nameOf(names, e.Player), e.PlayerCard, e.TargetCard, nameOf(names, e.Target))
+}
+
+func (e BaronCompare) VisibleTo(viewer int) bool { return viewer == e.Player || viewer == e.Target }
+
+// PrinceDiscard is recorded when a player is forced to discard their card (e.g. by a Prince).
+type PrinceDiscard struct {
+	Player int
+	Card   Card
+}
+
+func (e PrinceDiscard) Text(names []string) string {
+	return fmt.Sprintf("%s was forced to discard a %s", nameOf(names, e.Player), e.Card)
+}
+
+func (PrinceDiscard) VisibleTo(viewer int) bool { return true }
+
+// KingSwap is recorded when two players trade hands (e.g. with a King).
+type KingSwap struct {
+	Player, Target int
+}
+
+func (e KingSwap) Text(names []string) string {
+	return fmt.Sprintf("%s traded hands with %s", nameOf(names, e.Player), nameOf(names, e.Target))
+}
+
+func (KingSwap) VisibleTo(viewer int) bool { return true }
+
+// EliminationCause describes why a player was eliminated.
+type EliminationCause int
+
+const (
+	// EliminatedByCardEffect means a card eliminated the player in some other way (e.g. a custom card).
+	EliminatedByCardEffect = EliminationCause(iota)
+
+	// EliminatedByGuess means another player guessed the player's card (e.g. with a Guard).
+	EliminatedByGuess
+
+	// EliminatedByComparison means the player lost a comparison of cards (e.g. with a Baron).
+	EliminatedByComparison
+
+	// EliminatedByDiscard means the player discarded a card that eliminates them (e.g. the Princess).
+	EliminatedByDiscard
+
+	// EliminatedByIllegalAction means the player broke the rules.
+	EliminatedByIllegalAction
+)
+
+var descriptionOfCause = map[EliminationCause]string{
+	EliminatedByCardEffect:    "by a card",
+	EliminatedByGuess:         "by a correct guess",
+	EliminatedByComparison:    "by a comparison",
+	EliminatedByDiscard:       "by discarding their card",
+	EliminatedByIllegalAction: "for breaking the rules",
+}
+
+func (cause EliminationCause) String() string {
+	return descriptionOfCause[cause]
+}
+
+// Eliminated is recorded when a player is eliminated. The card in their hand is revealed.
+type Eliminated struct {
+	Player int
+	Cause  EliminationCause
+	Card   Card
+}
+
+func (e Eliminated) Text(names []string) string {
+	str := fmt.Sprintf("%s was eliminated %s!", nameOf(names, e.Player), e.Cause)
+	if e.Card != None {
+		str += fmt.Sprintf(" (they had a %s)", e.Card)
+	}
+	return str
+}
+
+func (Eliminated) VisibleTo(viewer int) bool { return true }
+
+// RoundEndReason describes how the winner of a game was decided.
+type RoundEndReason int
+
+const (
+	// LastPlayerStanding means every other player was eliminated.
+	LastPlayerStanding = RoundEndReason(iota)
+
+	// HighestCard means the deck ran out and the winner had the highest card.
+	HighestCard
+
+	// HighestDiscards means the deck ran out, the highest cards tied, and the winner had the highest total discards.
+	HighestDiscards
+
+	// SharedWin means the deck ran out, and the highest cards and the highest total discards both tied, so every tied
+	// player won.
+	SharedWin
+)
+
+// RoundEnd is recorded when the game ends.
+type RoundEnd struct {
+	Reason RoundEndReason
+
+	// Winner is the first of the Winners.
+	Winner int
+
+	// Winners contains every player who won. There's more than one for a SharedWin.
+	Winners []int
+
+	// Hands contains the card each player revealed when the deck ran out, or None for eliminated players.
+	// It's nil if the game ended because only one player remained.
+	Hands Stack
+
+	// Scores contains the total value of each player's discards when the highest cards tied.
+	Scores []int
+}
+
+func (e RoundEnd) Text(names []string) string {
+	winner := nameOf(names, e.Winner)
+	switch e.Reason {
+	case HighestCard:
+		revealed := []string{}
+		for _, card := range e.Hands {
+			if card != None {
+				revealed = append(revealed, card.String())
+			}
+		}
+		return winner + " won (" + strings.Join(revealed, " vs ") + ")"
+	case HighestDiscards, SharedWin:
+		scores := []string{}
+		for pid, score := range e.Scores {
+			if e.Hands[pid] != None {
+				scores = append(scores, fmt.Sprint(score))
+			}
+		}
+		if e.Reason == SharedWin {
+			winners := []string{}
+			for _, pid := range e.Winners {
+				winners = append(winners, nameOf(names, pid))
+			}
+			return strings.Join(winners, " and ") + " tied, so they share the win (" + strings.Join(scores, " to ") + ")"
+		}
+		return winner + " won (" + strings.Join(scores, " to ") + ")"
+	}
+	return winner + " won as the last player standing"
+}
+
+func (RoundEnd) VisibleTo(viewer int) bool { return true }
@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsAreRecordedInOrder(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4}, 2)
+	state.EventLog = newEventLog(2)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Prince
+	state.ActivePlayerCard = Priest
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1}, r)
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Baron}, r)
+
+	assert.Equal(t, []Event{
+		GameStarted{NumPlayers: 2},
+		CardPlayed{Player: 0, Card: Priest, Target: 1, SecondTarget: -1},
+		PriestReveal{Player: 0, Target: 1, Card: Prince},
+		CardPlayed{Player: 1, Card: Guard, Target: 0, SecondTarget: -1},
+		GuardGuess{Player: 1, Target: 0, Guess: Baron, Correct: true},
+		Eliminated{Player: 0, Cause: EliminatedByGuess, Card: Baron},
+		RoundEnd{Reason: LastPlayerStanding, Winner: 1, Winners: []int{1}},
+	}, state.EventLog.Events)
+}
+
+func TestEventLines(t *testing.T) {
+	el := EventLog{PlayerNames: []string{"Human", "Computer"}}
+	el.record(GameStarted{NumPlayers: 2})
+	el.record(CardPlayed{Player: 0, Card: Baron, Target: 1, SecondTarget: -1})
+	el.record(BaronCompare{Player: 0, Target: 1, PlayerCard: King, TargetCard: Guard})
+	el.record(Eliminated{Player: 1, Cause: EliminatedByComparison, Card: Guard})
+	el.record(RoundEnd{Reason: HighestCard, Winner: 0, Hands: Stack{King, Countess}})
+
+	assert.Equal(t, []string{
+		"New game with 2 players",
+		"Human played a Baron on Computer",
+		"Human revealed a King against a Guard from Computer",
+		"Computer was eliminated by a comparison! (they had a Guard)",
+		"Human won (King vs Countess)",
+	}, el.Lines())
+}
+
+func TestEventsVisibleTo(t *testing.T) {
+	el := EventLog{PlayerNames: []string{"A", "B", "C"}}
+	el.record(PriestReveal{Player: 0, Target: 1, Card: Prince})
+	el.record(BaronCompare{Player: 1, Target: 2, PlayerCard: King, TargetCard: Guard})
+	el.record(PrinceDiscard{Player: 2, Card: Handmaid})
+
+	assert.Len(t, el.VisibleTo(0).Events, 2)
+	assert.Len(t, el.VisibleTo(1).Events, 2)
+	assert.Len(t, el.VisibleTo(2).Events, 2)
+	assert.Equal(t, []Event{PrinceDiscard{Player: 2, Card: Handmaid}}, el.VisibleTo(Spectator).Events)
+	assert.Len(t, el.Events, 3, "Filtering doesn't change the original log")
+}
+
+func TestBlockedCardEvent(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = Baron
+	state.LastPlay[1] = Handmaid
+	state.ActivePlayerCard = Guard
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Priest}, r)
+
+	assert.Equal(t, []Event{
+		CardPlayed{Player: 0, Card: Guard, Target: 1, SecondTarget: -1, Blocked: true},
+	}, state.EventLog.Events)
+}
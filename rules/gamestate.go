@@ -47,25 +47,60 @@ type Gamestate struct {
 	GameEnded bool
 
 	// Winner is the id of the winning player. It is only valid once a player has won.
+	// If more than one player won, it's the first of the Winners.
 	Winner int
 
+	// Winners contains the ids of every player who won, in order. More than one player wins if the highest cards and
+	// the totals of their discards are tied. It's nil until the game ends.
+	Winners []int
+
 	// FinalState stores some state at the time the game ended. It's only set once GameEnded is true.
 	FinalState
 
 	// LossWasStupid is set to true if the play was something that would never, ever, be a good idea.
-	// This is only meaningful in 2-player.
+	// Only the active player can make such a play, so the player who lost this way is the one who was active when it was set.
 	LossWasStupid bool
 
 	// EventLog is a log of the events in this game.
 	EventLog
-}
 
-type EventLog struct {
-	// PlayerNames is a slice of player names.
-	PlayerNames []string
+	// CardSet defines the cards used in this game. If it's nil, ClassicCards is used.
+	CardSet *CardSet
+
+	// DeckBottom contains cards that were put on the bottom of the deck (e.g. by a Chancellor), in the order they will be drawn.
+	// They are only drawn once the rest of the deck (except for the face-down card) has been drawn.
+	DeckBottom Stack
+
+	// DeckBottomPlayers contains the player who put each card in DeckBottom there, who knows what it is, or -1 if no
+	// one does. It's nil if no one put any of them there.
+	DeckBottomPlayers []int
+
+	// PendingCard is a card the active player played that requires another action to resolve (e.g. a Chancellor).
+	// It is None if nothing is pending.
+	PendingCard Card
+
+	// PendingDraws contains the cards drawn by the PendingCard, which the active player must choose between.
+	// This is NOT public information.
+	PendingDraws Stack
+
+	// BonusTokens contains the number of bonus tokens each player earned this game (e.g. from a Spy or a Bishop).
+	// It's nil until a token is awarded, and complete once GameEnded is true.
+	BonusTokens []int
+
+	// ForcedTarget is the id of a player chosen by a Sycophant, who must be targeted by the next card played if it
+	// can be. It is -1 if no player was chosen.
+	ForcedTarget int
+
+	// JesterTokens contains, for each player, the id of the player who gave them a Jester token, or -1 if no one did.
+	// It's nil if no Jester was played.
+	JesterTokens []int
 
-	// Events is a slice of strings containing actions taken or results of those actions.
-	Events []string
+	// Recording receives every action and chance draw once StartRecording is called. Copies of the game don't record.
+	Recording *GameRecord
+
+	// Source decides which cards are drawn. If it's nil, cards are drawn at random using the *rand.Rand passed to
+	// PlayCard. Copies of the game don't keep the Source, so they can't see which cards will be drawn.
+	Source CardSource
 }
 
 type FinalState struct {
@@ -76,58 +111,109 @@ type FinalState struct {
 	LastInHand Card
 
 	// OpponentInHand was the card the opponent held at the start of the last play.
+	// With more than 2 players, the opponent is the winner if the active player lost; otherwise it's the player who
+	// was eliminated by the last play, or the runner-up if the deck ran out.
 	OpponentInHand Card
 
-	// RemainingDeck is the number of cards remaining in the deck at the end of the game.
+	// RemainingDeck is the number of cards remaining in the deck at the end of the game, including DeckBottom.
 	RemainingDeck int
 
-	// DiscardWon is true if the active player won.
+	// DiscardWon is true if the active player won (possibly sharing the win).
 	DiscardWon bool
+
+	// SharedWin is true if more than one player won.
+	SharedWin bool
 }
 
-// NewGame deals out a new game for the specified number of players.
-// This always assumes that player 0 is the starting player.
+// NewGame deals out a new game for the specified number of players, using ClassicCards for up to 4 players and
+// PremiumCards for more. This always assumes that player 0 is the starting player.
 func NewGame(playerCount int, r *rand.Rand) (Gamestate, error) {
-	state := newGame(DefaultDeck(), playerCount)
+	if playerCount > ClassicCards.MaxPlayers {
+		return NewGameWithCards(PremiumCards, playerCount, r)
+	}
+	return NewGameWithCards(ClassicCards, playerCount, r)
+}
+
+// NewGameWithCards deals out a new game for the specified number of players using the provided CardSet.
+// This always assumes that player 0 is the starting player.
+func NewGameWithCards(cards *CardSet, playerCount int, r *rand.Rand) (Gamestate, error) {
+	return deal(cards, playerCount, RandomSource{r})
+}
+
+// NewGameFromSource deals out a new game for the specified number of players using the provided CardSet, drawing
+// every card from the source (including the cards dealt). This always assumes that player 0 is the starting player.
+func NewGameFromSource(cards *CardSet, playerCount int, src CardSource) (Gamestate, error) {
+	state, err := deal(cards, playerCount, src)
+	state.Source = src
+	return state, err
+}
+
+func deal(cards *CardSet, playerCount int, src CardSource) (Gamestate, error) {
+	if playerCount < cards.MinPlayers || playerCount > cards.MaxPlayers {
+		return Gamestate{}, fmt.Errorf("Only games with %d to %d players are supported by the %s cards", cards.MinPlayers, cards.MaxPlayers, cards.Name)
+	}
+
+	state := newGame(cards.Deck(), playerCount)
+	state.CardSet = cards
 
 	if playerCount == 2 {
 		// Draw 3 cards face up
 		for i := 0; i < 3; i++ {
-			state.Faceup = append(state.Faceup, state.Deck.Draw(r))
+			state.Faceup = append(state.Faceup, src.Draw(&state.Deck))
 		}
-	} else if playerCount != 3 && playerCount != 4 {
-		return Gamestate{}, errors.New("Only games with 2, 3, or 4 players are supported")
 	}
 
 	for i := range state.CardInHand {
-		state.CardInHand[i] = state.Deck.Draw(r)
+		state.CardInHand[i] = src.Draw(&state.Deck)
 	}
-	state.ActivePlayerCard = state.Deck.Draw(r)
+	state.ActivePlayerCard = src.Draw(&state.Deck)
 
 	state.EventLog = newEventLog(playerCount)
 
 	return state, nil
 }
 
-func newEventLog(playerCount int) EventLog {
-	var el EventLog
-	el.log(fmt.Sprintf("New game with %d players", playerCount))
-	el.PlayerNames = make([]string, playerCount)
-	for i := 0; i < playerCount; i++ {
-		el.PlayerNames[i] = fmt.Sprintf("Player %d", i)
+// Reset deals a new game with the same cards and number of players, drawing at random. The game no longer has a Source.
+func (game *Gamestate) Reset(r *rand.Rand) error {
+	return game.redeal(RandomSource{r})
+}
+
+// ResetFromSource deals a new game with the same cards and number of players, drawing every card from the source.
+func (game *Gamestate) ResetFromSource(src CardSource) error {
+	if err := game.redeal(src); err != nil {
+		return err
 	}
-	return el
+	game.Source = src
+	return nil
 }
 
-func (game *Gamestate) Reset(r *rand.Rand) error {
+func (game *Gamestate) redeal(src CardSource) error {
 	oldEL := game.EventLog
 	var err error
-	*game, err = NewGame(game.NumPlayers, r)
+	*game, err = deal(game.cards(), game.NumPlayers, src)
 	if err != nil {
 		return err
 	}
 	game.EventLog = oldEL // Continue event log from before
-	game.EventLog.log("New Game")
+	game.EventLog.record(GameStarted{NumPlayers: game.NumPlayers})
+	return nil
+}
+
+// StartWith makes the player the first to play, e.g. when the winner of the last round starts the next one.
+// It must be called before anything is played, and before StartRecording.
+func (game *Gamestate) StartWith(player int) error {
+	if player < 0 || player >= game.NumPlayers || game.EliminatedPlayers[player] {
+		return fmt.Errorf("Player %d can't start the game", player)
+	}
+	if game.Recording != nil || game.GameEnded {
+		return errors.New("The first player can only be chosen before the game starts")
+	}
+	for _, discards := range game.Discards {
+		if len(discards) > 0 {
+			return errors.New("The first player can only be chosen before the game starts")
+		}
+	}
+	game.ActivePlayer = player
 	return nil
 }
 
@@ -144,6 +230,28 @@ func (game Gamestate) Copy() Gamestate {
 		Winner:           game.Winner,
 		FinalState:       game.FinalState,
 		LossWasStupid:    game.LossWasStupid,
+		CardSet:          game.CardSet,
+		DeckBottom:       game.DeckBottom.Copy(),
+		PendingCard:      game.PendingCard,
+		PendingDraws:     game.PendingDraws.Copy(),
+		ForcedTarget:     game.ForcedTarget,
+	}
+
+	if game.BonusTokens != nil {
+		gs.BonusTokens = make([]int, len(game.BonusTokens))
+		copy(gs.BonusTokens, game.BonusTokens)
+	}
+	if game.JesterTokens != nil {
+		gs.JesterTokens = make([]int, len(game.JesterTokens))
+		copy(gs.JesterTokens, game.JesterTokens)
+	}
+	if game.DeckBottomPlayers != nil {
+		gs.DeckBottomPlayers = make([]int, len(game.DeckBottomPlayers))
+		copy(gs.DeckBottomPlayers, game.DeckBottomPlayers)
+	}
+	if game.Winners != nil {
+		gs.Winners = make([]int, len(game.Winners))
+		copy(gs.Winners, game.Winners)
 	}
 
 	gs.Discards = make([]Stack, 0, len(game.Discards))
@@ -166,22 +274,6 @@ func (game Gamestate) Copy() Gamestate {
 	return gs
 }
 
-func (eventlog EventLog) Copy() EventLog {
-	el := EventLog{}
-
-	el.PlayerNames = make([]string, 0, len(eventlog.PlayerNames))
-	for _, val := range eventlog.PlayerNames {
-		el.PlayerNames = append(el.PlayerNames, val)
-	}
-
-	el.Events = make([]string, 0, len(eventlog.Events))
-	for _, val := range eventlog.Events {
-		el.Events = append(el.Events, val)
-	}
-
-	return el
-}
-
 // NewSimpleGame deals out a new game for 2 players with a simplified deck.
 // This always assumes that player 0 is the starting player.
 // It draws 4 cards from the previous deck:
@@ -215,6 +307,7 @@ func newGame(deck Deck, playerCount int) Gamestate {
 		ActivePlayerCard:  None,
 		GameEnded:         false,
 		Winner:            -1,
+		ForcedTarget:      -1,
 	}
 	for i := range state.KnownCards {
 		state.KnownCards[i] = make([]Card, playerCount)
@@ -230,9 +323,15 @@ func (state *Gamestate) AllDiscards() Deck {
 	return discards
 }
 
-func (state *Gamestate) eliminatePlayer(player int) {
-	// state.EventLog.logPlayer(player, "was eliminated!")
+func (state *Gamestate) eliminatePlayer(player int, cause EliminationCause) {
+	state.RecordEvent(Eliminated{Player: player, Cause: cause, Card: state.CardInHand[player]})
 	state.EliminatedPlayers[player] = true
+	state.knockedOut(player)
+
+	opponent := player
+	if player == state.ActivePlayer {
+		opponent = state.NextPlayer(player)
+	}
 
 	pInGame := 0
 	remainingPlayer := 0
@@ -244,30 +343,48 @@ func (state *Gamestate) eliminatePlayer(player int) {
 	}
 	if pInGame == 1 {
 		state.Winner = remainingPlayer
+		state.Winners = []int{remainingPlayer}
 		state.GameEnded = true
+		state.awardBonuses()
 	}
 
-	state.updateFinalState()
+	state.updateFinalState(opponent)
 
 	if state.CardInHand[player] != None {
-		state.Discards[state.ActivePlayer] = append(state.Discards[state.ActivePlayer], state.CardInHand[player])
+		state.Discards[player] = append(state.Discards[player], state.CardInHand[player])
 		state.CardInHand[player] = None
 	}
 	for i := range state.KnownCards[player] {
 		state.KnownCards[player][i] = None
 	}
+
+	if state.GameEnded {
+		state.RecordEvent(RoundEnd{Reason: LastPlayerStanding, Winner: state.Winner, Winners: []int{state.Winner}})
+	}
 }
 
-func (state *Gamestate) updateFinalState() {
+// updateFinalState records the FinalState, comparing the active player with the provided opponent.
+func (state *Gamestate) updateFinalState(opponent int) {
 	state.FinalState = FinalState{
 		LastDiscard:    state.ActivePlayerCard,
 		LastInHand:     state.CardInHand[state.ActivePlayer],
-		OpponentInHand: state.CardInHand[(state.ActivePlayer+1)%state.NumPlayers],
-		RemainingDeck:  state.Deck.Size(),
-		DiscardWon:     state.Winner == state.ActivePlayer,
+		OpponentInHand: state.CardInHand[opponent],
+		RemainingDeck:  state.Deck.Size() + len(state.DeckBottom),
+		DiscardWon:     state.IsWinner(state.ActivePlayer),
+		SharedWin:      len(state.Winners) > 1,
 	}
 }
 
+// IsWinner returns true if the player won the game, including when they share the win.
+func (state *Gamestate) IsWinner(player int) bool {
+	for _, winner := range state.Winners {
+		if winner == player {
+			return true
+		}
+	}
+	return false
+}
+
 func (state *Gamestate) clearKnownCard(player int, card Card) {
 	// Range through the list of my known cards to reset it if I discard the known card
 	for i, val := range state.KnownCards[player] {
@@ -282,241 +399,269 @@ func (state *Gamestate) PlayCard(action Action, r *rand.Rand) {
 	if state.GameEnded {
 		return
 	}
-
-	// If the card to be played isn't the recent card, swap them to make the rest of this function easier.
-	// Since that card will be discarded this turn, it doesn't matter that we do this.
-	if !action.PlayRecent {
-		card := state.ActivePlayerCard
-		state.ActivePlayerCard = state.CardInHand[state.ActivePlayer]
-		state.CardInHand[state.ActivePlayer] = card
+	if ValidateEveryPlay {
+		defer state.mustBeValid(state.ActivePlayer, action)
 	}
 
-	state.clearKnownCard(state.ActivePlayer, state.ActivePlayerCard)
-	state.Discards[state.ActivePlayer] = append(state.Discards[state.ActivePlayer], state.ActivePlayerCard)
-	state.LastPlay[state.ActivePlayer] = state.ActivePlayerCard
+	if state.Recording != nil {
+		state.Recording.Turns = append(state.Recording.Turns, Turn{Player: state.ActivePlayer, Action: action})
+	}
 
-	// If the retained card is the Countess, make sure that's allowed
-	if state.CardInHand[state.ActivePlayer] == Countess {
-		if state.ActivePlayerCard == King || state.ActivePlayerCard == Prince {
-			// Automatically eliminated for cheating. This is not the same as the rules, which simply forbid this.
-			// state.logPlayer(state.ActivePlayer, "cheated by failing to discard a Countess while holding a King/Prince")
-			state.eliminatePlayer(state.ActivePlayer)
-			state.LossWasStupid = true
-			return
+	if state.PendingCard != None {
+		state.resolvePendingCard(action, r)
+	} else {
+		// If the card to be played isn't the recent card, swap them to make the rest of this function easier.
+		// Since that card will be discarded this turn, it doesn't matter that we do this.
+		if !action.PlayRecent {
+			card := state.ActivePlayerCard
+			state.ActivePlayerCard = state.CardInHand[state.ActivePlayer]
+			state.CardInHand[state.ActivePlayer] = card
 		}
+
+		state.clearKnownCard(state.ActivePlayer, state.ActivePlayerCard)
+		state.Discards[state.ActivePlayer] = append(state.Discards[state.ActivePlayer], state.ActivePlayerCard)
+		state.LastPlay[state.ActivePlayer] = state.ActivePlayerCard
+
+		state.applyCard(action, r)
 	}
 
-	switch state.ActivePlayerCard {
-	case Guard:
-		if !(action.TargetPlayerOffset > 0 && action.TargetPlayerOffset < state.NumPlayers) {
-			// You must target a valid player with a Guard
-			// state.logPlayer(state.ActivePlayer, "played a Guard against an invalid player")
-			state.eliminatePlayer(state.ActivePlayer)
-			state.LossWasStupid = true
-			break
-		}
-		targetPlayer := state.getTargetIDFromOffset(action.TargetPlayerOffset)
-		if state.LastPlay[targetPlayer] == Handmaid {
-			// state.logPlayer(state.ActivePlayer, "played a Guard, but it was blocked by a Handmaid")
-			break
-		}
-		targetCard := state.CardInHand[targetPlayer]
-		// state.logPlayer(state.ActivePlayer, "played a Guard, guessing "+action.SelectedCard.String())
-		if targetCard == action.SelectedCard && targetCard != Guard {
-			state.eliminatePlayer(targetPlayer)
-		}
-		// Note we don't store this history, which a real player would rely upon. e.g. if I guess 4 and it's wrong, do I guess 4 again the next turn when no Handmaids have shown up? This bot would do that.
-	case Priest:
-		if !(action.TargetPlayerOffset > 0 && action.TargetPlayerOffset < state.NumPlayers) {
-			// You must target a valid player with a Priest
-			// state.logPlayer(state.ActivePlayer, "played a Priest against an invalid player")
-			state.eliminatePlayer(state.ActivePlayer)
-			state.LossWasStupid = true
-			break
-		}
-		targetPlayer := state.getTargetIDFromOffset(action.TargetPlayerOffset)
-		if state.LastPlay[targetPlayer] == Handmaid {
-			// state.logPlayer(state.ActivePlayer, "played a Priest, but it was blocked by a Handmaid")
-			break
-		}
-		// state.logPlayer(state.ActivePlayer, "played a Priest and saw a "+state.CardInHand[targetPlayer].String())
-		state.KnownCards[targetPlayer][state.ActivePlayer] = state.CardInHand[targetPlayer]
-	case Baron:
-		if !(action.TargetPlayerOffset > 0 && action.TargetPlayerOffset < state.NumPlayers) {
-			// You must target a valid player with a Baron
-			// state.logPlayer(state.ActivePlayer, "played a Baron against an invalid player")
-			state.eliminatePlayer(state.ActivePlayer)
-			state.LossWasStupid = true
-			break
-		}
-		targetPlayer := state.getTargetIDFromOffset(action.TargetPlayerOffset)
-		if state.LastPlay[targetPlayer] == Handmaid {
-			// state.logPlayer(state.ActivePlayer, "played a Baron, but it was blocked by a Handmaid")
-			break
-		}
-		// Compare cards. Eliminate low. Tie does nothing
-		targetCard := state.CardInHand[targetPlayer]
-		activeCard := state.CardInHand[state.ActivePlayer]
-		// state.logPlayer(state.ActivePlayer, "played a Baron, revealing a "+activeCard.String()+" against a "+targetCard.String())
-		switch {
-		case int(targetCard) < int(activeCard):
-			state.eliminatePlayer(targetPlayer)
-		case int(targetCard) > int(activeCard):
-			state.eliminatePlayer(state.ActivePlayer)
-		}
-	case Handmaid:
-		// state.logPlayer(state.ActivePlayer, "played a Handmaid")
-		// Do nothing
-	case Prince:
-		if !(action.TargetPlayerOffset >= 0 && action.TargetPlayerOffset < state.NumPlayers) {
-			// You must target a valid player with a Prince
-			// state.logPlayer(state.ActivePlayer, "played a Prince against an invalid player")
-			state.eliminatePlayer(state.ActivePlayer)
-			state.LossWasStupid = true
-			break
-		}
-		targetPlayer := state.getTargetIDFromOffset(action.TargetPlayerOffset)
-		if state.LastPlay[targetPlayer] == Handmaid {
-			// If you target someone invalid, default to self.
-			// The game rules say that if everyone else has a Handmaid, you must target yourself, so this is a good default.
-			targetPlayer = state.ActivePlayer
-		}
-		targetCard := state.CardInHand[targetPlayer]
-		if targetPlayer == state.ActivePlayer {
-			// state.logPlayer(state.ActivePlayer, "played a self-Prince, forcing a "+targetCard.String()+" to be discarded")
-		} else {
-			// state.logPlayer(state.ActivePlayer, "played a Prince, forcing a "+targetCard.String()+" to be discarded")
-		}
-		if targetCard == Princess {
-			// Do this first to update FinalState.
-			state.eliminatePlayer(targetPlayer)
-		} else {
-			state.Discards[targetPlayer] = append(state.Discards[targetPlayer], targetCard)
-			state.CardInHand[targetPlayer] = state.Deck.Draw(r)
-		}
-		state.clearKnownCard(targetPlayer, targetCard)
-		if targetCard == Princess && targetPlayer == state.ActivePlayer {
-			// This was stupid UNLESS the other player has a Handmaid, in which case this is okay.
-			if state.NumPlayers == 2 && state.LastPlay[(state.ActivePlayer+1)%2] != Handmaid {
-				state.LossWasStupid = true
-			}
-		}
-	case King:
-		if !(action.TargetPlayerOffset > 0 && action.TargetPlayerOffset < state.NumPlayers) {
-			// You must target a valid player with a King
-			// state.logPlayer(state.ActivePlayer, "played a King against an invalid player")
-			state.eliminatePlayer(state.ActivePlayer)
-			state.LossWasStupid = true
-			break
-		}
-		targetPlayer := state.getTargetIDFromOffset(action.TargetPlayerOffset)
-		if state.LastPlay[targetPlayer] == Handmaid {
-			// state.logPlayer(state.ActivePlayer, "played a King, but it was blocked by a Handmaid")
-			break
-		}
-		// Trade hands
-		targetCard := state.CardInHand[targetPlayer]
-		activeCard := state.CardInHand[state.ActivePlayer]
-		state.CardInHand[targetPlayer] = activeCard
-		state.CardInHand[state.ActivePlayer] = targetCard
-		// Update knowledge
-		for i := range state.KnownCards[state.ActivePlayer] {
-			if activeCard == state.KnownCards[state.ActivePlayer][i] {
-				state.KnownCards[state.ActivePlayer][i] = targetCard
-			}
-			if targetCard == state.KnownCards[targetPlayer][i] {
-				state.KnownCards[targetPlayer][i] = activeCard
-			}
-		}
-		state.KnownCards[state.ActivePlayer][targetPlayer] = targetCard
-		state.KnownCards[targetPlayer][state.ActivePlayer] = activeCard
-		// state.logPlayer(state.ActivePlayer, "played a King")
-	case Countess:
-		// Do nothing
-		// state.logPlayer(state.ActivePlayer, "played a Countess")
-	case Princess:
-		// Idiot!
-		// state.logPlayer(state.ActivePlayer, "played a Princess")
-		state.eliminatePlayer(state.ActivePlayer)
-		state.LossWasStupid = true
-	default:
-		// An invalid card was played
-		// state.logPlayer(state.ActivePlayer, "played an invalid card (ERROR!)")
-		state.eliminatePlayer(state.ActivePlayer)
-		state.LossWasStupid = true
+	if state.GameEnded || state.PendingCard != None {
+		// Either the game is over, or the active player needs to take another action
+		return
 	}
 
-	if state.Deck.Size() > 1 {
-		state.ActivePlayerCard = state.Deck.Draw(r)
+	if state.DrawPileSize() > 0 {
+		state.ActivePlayerCard = state.Draw(r)
 		state.incrementPlayerTurn()
 	} else {
 		state.triggerGameEnd()
 	}
 }
 
+// resolvePendingCard passes the action to the PendingCard's Resolver.
+// If the action isn't a valid resolution, the active player is eliminated.
+func (state *Gamestate) resolvePendingCard(action Action, r *rand.Rand) {
+	resolver, ok := state.cards().Definition(state.PendingCard).(Resolver)
+	if !ok || !state.IsLegal(action) {
+		// Automatically eliminated for cheating. The drawn cards go to the bottom of the deck.
+		state.putOnBottom(state.ActivePlayer, state.PendingDraws)
+		state.PendingCard = None
+		state.PendingDraws = nil
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByIllegalAction)
+		state.LossWasStupid = true
+		return
+	}
+	resolver.Resolve(state, action, r)
+	state.PendingCard = None
+	state.PendingDraws = nil
+}
+
+// DrawPileSize returns the number of cards that can still be drawn, not counting the face-down card.
+func (state Gamestate) DrawPileSize() int {
+	size := state.Deck.Size() - 1
+	if size < 0 {
+		size = 0
+	}
+	return size + len(state.DeckBottom)
+}
+
+// Draw removes a card from the deck. Cards on the bottom of the deck are only drawn once the rest of the draw pile is
+// empty, and the face-down card is only drawn once there is nothing else.
+func (state *Gamestate) Draw(r *rand.Rand) Card {
+	if state.Deck.Size() > 1 || len(state.DeckBottom) == 0 {
+		return state.drawChance(r)
+	}
+	card := state.DeckBottom[0]
+	state.DeckBottom = state.DeckBottom[1:]
+	if len(state.DeckBottomPlayers) > 1 {
+		state.DeckBottomPlayers = state.DeckBottomPlayers[1:]
+	} else {
+		state.DeckBottomPlayers = nil
+	}
+	return card
+}
+
+// putOnBottom puts the player's cards on the bottom of the deck, after the cards already there.
+func (state *Gamestate) putOnBottom(player int, cards Stack) {
+	if state.DeckBottomPlayers == nil {
+		state.DeckBottomPlayers = make([]int, len(state.DeckBottom))
+		for i := range state.DeckBottomPlayers {
+			state.DeckBottomPlayers[i] = -1
+		}
+	}
+	for range cards {
+		state.DeckBottomPlayers = append(state.DeckBottomPlayers, player)
+	}
+	state.DeckBottom = append(state.DeckBottom, cards...)
+}
+
+// bottomPlayer returns the player who put the card at index i of DeckBottom there, or -1 if no one did.
+func (state *Gamestate) bottomPlayer(i int) int {
+	if i >= len(state.DeckBottomPlayers) {
+		return -1
+	}
+	return state.DeckBottomPlayers[i]
+}
+
+// applyCard applies the effect of the active player's card, which has already been discarded.
+// Anything that breaks the rules eliminates the active player.
+func (state *Gamestate) applyCard(action Action, r *rand.Rand) {
+	played := CardPlayed{Player: state.ActivePlayer, Card: state.ActivePlayerCard, Target: -1, SecondTarget: -1}
+	cards := state.cards()
+	def := cards.Definition(state.ActivePlayerCard)
+	if def == nil {
+		// An invalid card was played
+		state.RecordEvent(played)
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByIllegalAction)
+		state.LossWasStupid = true
+		return
+	}
+
+	// If the retained card is the Countess, make sure that's allowed
+	if kept := cards.Definition(state.CardInHand[state.ActivePlayer]); kept != nil && kept.MustBePlayedInsteadOf(state.ActivePlayerCard) {
+		// Automatically eliminated for cheating. This is not the same as the rules, which simply forbid this.
+		state.RecordEvent(played)
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByIllegalAction)
+		state.LossWasStupid = true
+		return
+	}
+
+	blocked, violation, ok := state.checkTargets(def.Targeting(), action)
+	// A Sycophant's choice only applies to the next card played
+	state.ForcedTarget = -1
+	if ok || violation == ProtectedTarget {
+		played.Target, played.SecondTarget = state.targetsOf(def.Targeting(), action)
+	}
+	switch {
+	case !ok && violation == ProtectedTarget && def.Targeting() == TargetAnyPlayer:
+		// If you target someone invalid, default to self.
+		// The game rules say that if everyone else has a Handmaid, you must target yourself, so this is a good default.
+		played.Target = state.ActivePlayer
+	case !ok && violation == ProtectedTarget, blocked:
+		played.Blocked = true
+		state.RecordEvent(played)
+		return
+	case !ok:
+		// You must target a valid player
+		state.RecordEvent(played)
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByIllegalAction)
+		state.LossWasStupid = true
+		return
+	}
+	state.RecordEvent(played)
+
+	target := played.Target
+	if target < 0 {
+		target = state.ActivePlayer
+	}
+	def.Play(state, action, target, r)
+}
+
+// targetsOf returns the ids of the players targeted by the action, or -1 for targets the card doesn't have.
+func (state *Gamestate) targetsOf(targeting Targeting, action Action) (int, int) {
+	switch targeting {
+	case NoTarget:
+		return -1, -1
+	case TargetTwoPlayers:
+		return state.getTargetIDFromOffset(action.TargetPlayerOffset), state.getTargetIDFromOffset(action.SecondTargetOffset)
+	case TargetOneOrTwoOpponents:
+		if action.SecondTargetOffset != 0 {
+			return state.getTargetIDFromOffset(action.TargetPlayerOffset), state.getTargetIDFromOffset(action.SecondTargetOffset)
+		}
+	}
+	return state.getTargetIDFromOffset(action.TargetPlayerOffset), -1
+}
+
 func (state *Gamestate) getTargetIDFromOffset(offset int) int {
 	return (state.ActivePlayer + offset) % state.NumPlayers
 }
 
-// incrementPlayerTurn increments the player turn. It assumes there are at least 2 active players
-func (state *Gamestate) incrementPlayerTurn() {
-	// Increment with rollover
-	state.ActivePlayer = (state.ActivePlayer + 1) % state.NumPlayers
+// isValidOpponentOffset returns true if the offset refers to another player who is still in the game.
+func (state *Gamestate) isValidOpponentOffset(offset int) bool {
+	return offset > 0 && offset < state.NumPlayers && !state.EliminatedPlayers[state.getTargetIDFromOffset(offset)]
+}
 
-	// Skip past eliminated players
-	for state.EliminatedPlayers[state.ActivePlayer] {
-		state.ActivePlayer = (state.ActivePlayer + 1) % state.NumPlayers
+// NextPlayer returns the id of the first player after the provided player (in turn order) who hasn't been eliminated.
+// If every other player has been eliminated, the provided player is returned.
+func (state Gamestate) NextPlayer(player int) int {
+	for offset := 1; offset < state.NumPlayers; offset++ {
+		next := (player + offset) % state.NumPlayers
+		if !state.EliminatedPlayers[next] {
+			return next
+		}
 	}
+	return player
 }
 
+// incrementPlayerTurn increments the player turn, skipping past eliminated players. It assumes there are at least 2 active players
+func (state *Gamestate) incrementPlayerTurn() {
+	state.ActivePlayer = state.NextPlayer(state.ActivePlayer)
+}
+
+// triggerGameEnd ends the game when the deck runs out. The player with the highest card wins. If the highest cards
+// tie, the tied player with the highest total of discards wins, and if those tie too, every tied player wins.
 func (state *Gamestate) triggerGameEnd() {
 	// It's an error if the deck size is > 1, but test code in this module could confirm that never happens.
-	tie := false
-	maxCard := 0
-	state.Winner = 0
-	for pid, val := range state.CardInHand {
-		if int(val) > maxCard {
-			maxCard = int(val)
-			state.Winner = pid
-			tie = false
-		} else if int(val) == maxCard {
-			tie = true
+	maxCard := -1
+	highest := []int{}
+	cards := state.cards()
+	for pid := range state.CardInHand {
+		if state.EliminatedPlayers[pid] {
+			continue
+		}
+		val := state.handValue(pid)
+		if val > maxCard {
+			maxCard = val
+			highest = []int{pid}
+		} else if val == maxCard {
+			highest = append(highest, pid)
 		}
 	}
 
-	if tie {
+	if len(highest) > 1 {
 		scores := make([]int, len(state.Discards))
-		maxScore := 0
+		maxScore := -1
+		winners := []int{}
 		for i := range scores {
-			for _, val := range state.Discards[i] {
-				scores[i] += int(val)
+			if state.EliminatedPlayers[i] {
+				continue
+			}
+			scores[i] = cards.Score(state.Discards[i])
+			if state.handValue(i) != maxCard {
+				// Only players who tied for the highest card can win
+				continue
 			}
 			if scores[i] > maxScore {
 				maxScore = scores[i]
-				state.Winner = i
-				tie = false
+				winners = []int{i}
 			} else if scores[i] == maxScore {
-				tie = true // We don't deal with this
+				winners = append(winners, i)
 			}
 		}
-		// state.logPlayer(state.Winner, fmt.Sprintf("won (%d to %d)", scores[0], scores[1]))
+		state.Winners = winners
+		reason := HighestDiscards
+		if len(winners) > 1 {
+			reason = SharedWin
+		}
+		state.RecordEvent(RoundEnd{Reason: reason, Winner: winners[0], Winners: winners, Hands: state.CardInHand.Copy(), Scores: scores})
 	} else {
-		// state.logPlayer(state.Winner, "won ("+state.CardInHand[0].String()+" vs "+state.CardInHand[1].String()+")")
+		state.Winners = highest
+		state.RecordEvent(RoundEnd{Reason: HighestCard, Winner: highest[0], Winners: highest, Hands: state.CardInHand.Copy()})
 	}
+	state.Winner = state.Winners[0]
 
 	state.GameEnded = true
-
-	state.updateFinalState()
-}
-
-func (el *EventLog) logPlayer(player int, event string) {
-	name := ""
-	if len(el.PlayerNames) > player {
-		name = el.PlayerNames[player]
+	state.awardBonuses()
+
+	// The opponent is the winner, or the runner-up if the active player won.
+	opponent := state.Winner
+	if opponent == state.ActivePlayer {
+		opponent = state.NextPlayer(state.ActivePlayer)
+		for pid := range state.CardInHand {
+			if pid != state.ActivePlayer && !state.EliminatedPlayers[pid] && state.handValue(pid) > state.handValue(opponent) {
+				opponent = pid
+			}
+		}
 	}
-	el.log(name + " " + event)
-}
-
-func (el *EventLog) log(event string) {
-	el.Events = append([]string{event}, el.Events...)
+	state.updateFinalState(opponent)
 }
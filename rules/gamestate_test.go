@@ -288,3 +288,195 @@ func TestLastPlay(t *testing.T) {
 	assert.True(t, state.GameEnded)
 	assert.Equal(t, 1, state.Winner)
 }
+
+func TestTiedHandsHighestDiscardsWins(t *testing.T) {
+	state := newGame(Deck{Prince: 1}, 2)
+	state.CardInHand[0] = King
+	state.CardInHand[1] = King
+	state.Discards[0] = Stack{Priest}
+	state.ActivePlayerCard = Guard
+	state.ActivePlayer = 1
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Handmaid}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 0, state.Winner)
+	assert.Equal(t, []int{0}, state.Winners)
+	assert.False(t, state.FinalState.SharedWin)
+	assert.False(t, state.FinalState.DiscardWon)
+	end := state.EventLog.Events[len(state.EventLog.Events)-1].(RoundEnd)
+	assert.Equal(t, HighestDiscards, end.Reason)
+}
+
+func TestTiedDiscardsShareTheWin(t *testing.T) {
+	state := newGame(Deck{Prince: 1}, 2)
+	state.CardInHand[0] = King
+	state.CardInHand[1] = King
+	state.Discards[0] = Stack{Guard}
+	state.ActivePlayerCard = Guard
+	state.ActivePlayer = 1
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Handmaid}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, []int{0, 1}, state.Winners)
+	assert.Equal(t, 0, state.Winner)
+	assert.True(t, state.IsWinner(0))
+	assert.True(t, state.IsWinner(1))
+	assert.True(t, state.FinalState.SharedWin)
+	assert.True(t, state.FinalState.DiscardWon)
+	end := state.EventLog.Events[len(state.EventLog.Events)-1].(RoundEnd)
+	assert.Equal(t, SharedWin, end.Reason)
+	assert.Equal(t, "Player 0 and Player 1 tied, so they share the win (1 to 1)", end.Text(state.EventLog.PlayerNames))
+}
+
+func TestEliminatedCardGoesToEliminatedPlayer(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4, Priest: 2}, 3)
+
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Guard
+	state.CardInHand[2] = Countess
+	state.ActivePlayerCard = Guard
+
+	// Guess the Countess held by player 2
+	state.PlayCard(Action{
+		PlayRecent:         true,
+		TargetPlayerOffset: 2,
+		SelectedCard:       Countess,
+	}, r)
+
+	assert.False(t, state.GameEnded)
+	assert.True(t, state.EliminatedPlayers[2])
+	assert.Equal(t, Stack{Guard}, state.Discards[0])
+	assert.Equal(t, Stack{Countess}, state.Discards[2])
+	assert.Equal(t, None, state.CardInHand[2])
+	assert.Equal(t, 1, state.ActivePlayer)
+}
+
+func TestTurnSkipsEliminatedPlayer(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4, Priest: 2}, 4)
+
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Guard
+	state.CardInHand[2] = None
+	state.CardInHand[3] = Baron
+	state.EliminatedPlayers[2] = true
+	state.ActivePlayerCard = Handmaid
+	state.ActivePlayer = 1
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.Equal(t, 3, state.ActivePlayer)
+
+	state.PlayCard(Action{PlayRecent: false, TargetPlayerOffset: 3}, r) // Baron vs player 2 (offset 3 from player 3), who is eliminated
+	assert.True(t, state.EliminatedPlayers[3], "Targeting an eliminated player is cheating")
+	assert.Equal(t, 0, state.ActivePlayer)
+}
+
+func TestPrinceOnSelfWhenEveryoneHasHandmaid(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4, Priest: 2}, 3)
+
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Guard
+	state.CardInHand[2] = Baron
+	state.LastPlay[1] = Handmaid
+	state.LastPlay[2] = Handmaid
+	state.ActivePlayerCard = Prince
+
+	assert.Equal(t, []Action{
+		{PlayRecent: true, TargetPlayerOffset: 0},
+		{PlayRecent: false, TargetPlayerOffset: 1},
+	}, state.LegalActions())
+
+	// Targeting a protected player defaults to targeting yourself
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 2}, r)
+
+	assert.Equal(t, Stack{Prince, Priest}, state.Discards[0])
+	assert.Equal(t, Guard, state.CardInHand[1])
+	assert.Equal(t, Baron, state.CardInHand[2])
+	assert.Equal(t, 1, state.ActivePlayer)
+}
+
+func TestPrinceOnOwnPrincessWhenEveryoneHasHandmaidIsNotStupid(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4, Priest: 2}, 3)
+
+	state.CardInHand[0] = Princess
+	state.CardInHand[1] = Guard
+	state.CardInHand[2] = Baron
+	state.LastPlay[1] = Handmaid
+	state.LastPlay[2] = Handmaid
+	state.ActivePlayerCard = Prince
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 0}, r)
+
+	assert.True(t, state.EliminatedPlayers[0])
+	assert.False(t, state.LossWasStupid)
+	assert.False(t, state.GameEnded)
+	assert.Equal(t, 1, state.ActivePlayer)
+}
+
+func TestMultiplayerLastPlayerStanding(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4, Priest: 2}, 3)
+
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = None
+	state.CardInHand[2] = Countess
+	state.EliminatedPlayers[1] = true
+	state.ActivePlayerCard = Guard
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 2, SelectedCard: Countess}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 0, state.Winner)
+	assert.Equal(t, Countess, state.FinalState.OpponentInHand)
+	assert.True(t, state.FinalState.DiscardWon)
+}
+
+func TestMultiplayerGamesComplete(t *testing.T) {
+	for _, numPlayers := range []int{3, 4} {
+		for i := 0; i < 100; i++ {
+			state, err := NewGame(numPlayers, r)
+			assert.NoError(t, err)
+			for !state.GameEnded {
+				assert.False(t, state.EliminatedPlayers[state.ActivePlayer], "Eliminated players don't take turns")
+				acts := state.LegalActions()
+				assert.NoError(t, state.TryPlayCard(acts[r.Intn(len(acts))], r))
+			}
+			assert.False(t, state.EliminatedPlayers[state.Winner], "The winner was not eliminated")
+
+			// Every card is accounted for
+			cards := state.AllDiscards()
+			cards.AddStack(state.CardInHand)
+			for i := range cards {
+				cards[i] += state.Deck[i]
+			}
+			cards[None] = 0
+			assert.Equal(t, DefaultDeck(), cards)
+		}
+	}
+}
+
+func TestStartWith(t *testing.T) {
+	game, _ := NewGame(4, r)
+	assert.Error(t, game.StartWith(4))
+	assert.NoError(t, game.StartWith(2))
+	assert.Equal(t, 2, game.ActivePlayer)
+
+	acts := game.LegalActions()
+	game.PlayCard(acts[0], r)
+	assert.Error(t, game.StartWith(0), "The game has started")
+}
+
+func TestTokensNeeded(t *testing.T) {
+	assert.Equal(t, 7, ClassicCards.TokensNeeded(2))
+	assert.Equal(t, 5, ClassicCards.TokensNeeded(3))
+	assert.Equal(t, 4, ClassicCards.TokensNeeded(4))
+	assert.Equal(t, 6, Edition2019Cards.TokensNeeded(2))
+	assert.Equal(t, 3, Edition2019Cards.TokensNeeded(6))
+	assert.Equal(t, 3, PremiumCards.TokensNeeded(8))
+}
@@ -0,0 +1,358 @@
+package rules
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// LegalActions returns every Action the active player may take according to the rules.
+// A player holding the Countess with a King or Prince may only play the Countess. Cards that target an opponent can
+// only target players who are still in the game and not protected by a Handmaid; if there is no such opponent, a
+// single action targeting the next remaining opponent is returned, which the card then has no effect on.
+// A Prince may always target its own player.
+// If both cards in hand are the same, actions are returned for playing each of them, even though they are equivalent.
+// Playing the Princess is allowed (although it eliminates the player), so it is included.
+func (state Gamestate) LegalActions() []Action {
+	if state.GameEnded {
+		return nil
+	}
+
+	if state.PendingCard != None {
+		if resolver, ok := state.cards().Definition(state.PendingCard).(Resolver); ok {
+			return resolver.Resolutions(state)
+		}
+		return nil
+	}
+
+	recent := state.ActivePlayerCard
+	old := state.CardInHand[state.ActivePlayer]
+
+	acts := []Action{}
+	acts = append(acts, state.legalActionsForCard(true, recent, old)...)
+	acts = append(acts, state.legalActionsForCard(false, old, recent)...)
+	return acts
+}
+
+// IsLegal returns true if the action is one of the actions returned by LegalActions.
+func (state Gamestate) IsLegal(action Action) bool {
+	for _, act := range state.LegalActions() {
+		if act == action {
+			return true
+		}
+	}
+	return false
+}
+
+// legalActionsForCard returns the legal actions when playing card while keeping other.
+func (state Gamestate) legalActionsForCard(isRecent bool, card, other Card) []Action {
+	cards := state.cards()
+	def := cards.Definition(card)
+	if def == nil {
+		// Anything else isn't a card that can be played
+		return nil
+	}
+	if kept := cards.Definition(other); kept != nil && kept.MustBePlayedInsteadOf(card) {
+		// The other card (e.g. the Countess) must be played instead
+		return nil
+	}
+
+	if def.Targeting() == NoTarget {
+		return []Action{{PlayRecent: isRecent}}
+	}
+
+	selections := state.targetSelections(def.Targeting())
+	if len(selections) == 0 {
+		return state.blockedActions(isRecent)
+	}
+
+	guesses := []Card{None}
+	if def.Targeting() == GuessOpponent {
+		guesses = state.guessableCards(def, card)
+	}
+	acts := make([]Action, 0, len(selections)*len(guesses))
+	for _, sel := range selections {
+		for _, guess := range guesses {
+			acts = append(acts, Action{
+				PlayRecent:         isRecent,
+				TargetPlayerOffset: sel[0],
+				SecondTargetOffset: sel[1],
+				SelectedCard:       guess,
+			})
+		}
+	}
+	return acts
+}
+
+// guessableCards returns the cards that can be guessed when playing the provided card (e.g. any card but a Guard).
+func (state Gamestate) guessableCards(def CardDefinition, played Card) []Card {
+	if guesser, ok := def.(Guesser); ok {
+		return guesser.Guesses(state.cards())
+	}
+	guesses := []Card{}
+	for _, card := range state.cards().Cards() {
+		if card != played {
+			guesses = append(guesses, card)
+		}
+	}
+	return guesses
+}
+
+// isGuessable returns true if the guess is one of the guessableCards.
+func (state Gamestate) isGuessable(def CardDefinition, played, guess Card) bool {
+	for _, card := range state.guessableCards(def, played) {
+		if card == guess {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedActions returns the action used to play a targeting card when every opponent is protected.
+func (state Gamestate) blockedActions(isRecent bool) []Action {
+	for offset := 1; offset < state.NumPlayers; offset++ {
+		if !state.EliminatedPlayers[state.getTargetIDFromOffset(offset)] {
+			return []Action{{PlayRecent: isRecent, TargetPlayerOffset: offset}}
+		}
+	}
+	return nil
+}
+
+// targetSelections returns every legal combination of TargetPlayerOffset and SecondTargetOffset for a card with the
+// provided targeting. The SecondTargetOffset is always 0 unless the card targets two players.
+// If a Sycophant forced a target and some selections include it, only those selections are returned.
+func (state Gamestate) targetSelections(targeting Targeting) [][2]int {
+	offsets := state.targetableOffsets()
+	if targeting == TargetAnyPlayer || targeting == TargetTwoPlayers {
+		// These cards can always target yourself
+		offsets = append([]int{0}, offsets...)
+	}
+
+	selections := [][2]int{}
+	switch targeting {
+	case TargetOpponent, GuessOpponent, TargetAnyPlayer:
+		for _, offset := range offsets {
+			selections = append(selections, [2]int{offset, 0})
+		}
+	case TargetOneOrTwoOpponents:
+		for i, offset := range offsets {
+			selections = append(selections, [2]int{offset, 0})
+			for _, second := range offsets[i+1:] {
+				selections = append(selections, [2]int{offset, second})
+			}
+		}
+	case TargetTwoPlayers:
+		for _, offset := range offsets {
+			for _, second := range offsets {
+				if offset != second {
+					selections = append(selections, [2]int{offset, second})
+				}
+			}
+		}
+	}
+
+	forced := state.forcedOffset()
+	if forced < 0 {
+		return selections
+	}
+	forcedSelections := [][2]int{}
+	for _, sel := range selections {
+		hasSecond := targeting == TargetTwoPlayers || (targeting == TargetOneOrTwoOpponents && sel[1] != 0)
+		if sel[0] == forced || (hasSecond && sel[1] == forced) {
+			forcedSelections = append(forcedSelections, sel)
+		}
+	}
+	if len(forcedSelections) == 0 {
+		// The forced target can't be targeted by this card, so it doesn't matter
+		return selections
+	}
+	return forcedSelections
+}
+
+// forcedOffset returns the offset of the ForcedTarget, or -1 if there is none.
+func (state Gamestate) forcedOffset() int {
+	if state.ForcedTarget < 0 || state.ForcedTarget >= state.NumPlayers || state.EliminatedPlayers[state.ForcedTarget] {
+		return -1
+	}
+	return (state.ForcedTarget - state.ActivePlayer + state.NumPlayers) % state.NumPlayers
+}
+
+// checkTargets checks if the action selects legal targets for a card with the provided targeting.
+// If the card has no legal targets because every opponent is protected, any remaining opponent is allowed, and
+// blocked is true because the card has no effect.
+func (state Gamestate) checkTargets(targeting Targeting, action Action) (blocked bool, err Violation, ok bool) {
+	if targeting == NoTarget {
+		return false, 0, true
+	}
+
+	sel := [2]int{action.TargetPlayerOffset, action.SecondTargetOffset}
+	hasSecond := targeting == TargetTwoPlayers || (targeting == TargetOneOrTwoOpponents && sel[1] != 0)
+	if !hasSecond {
+		sel[1] = 0
+	}
+
+	selections := state.targetSelections(targeting)
+	for _, legal := range selections {
+		// Two opponents can be chosen in either order, but neither can be left out
+		swapped := targeting == TargetOneOrTwoOpponents && sel[0] != 0 && legal == [2]int{sel[1], sel[0]}
+		if legal == sel || swapped {
+			return false, 0, true
+		}
+	}
+
+	// Find out why the selection isn't legal
+	selfAllowed := targeting == TargetAnyPlayer || targeting == TargetTwoPlayers
+	chosen := sel[:1]
+	if hasSecond {
+		if sel[0] == sel[1] {
+			return false, InvalidTarget, false
+		}
+		chosen = sel[:]
+	}
+	protected := false
+	for _, offset := range chosen {
+		if offset == 0 && selfAllowed {
+			continue
+		}
+		if !state.isValidOpponentOffset(offset) {
+			return false, InvalidTarget, false
+		}
+		protected = protected || state.isProtected(state.getTargetIDFromOffset(offset))
+	}
+
+	if len(selections) == 0 {
+		// Every possible target is protected, so the card is played without effect
+		return true, 0, true
+	}
+	if protected {
+		return false, ProtectedTarget, false
+	}
+	return false, MustTargetForced, false
+}
+
+// targetableOffsets returns the offsets of all opponents who are still in the game and aren't protected by a Handmaid.
+func (state Gamestate) targetableOffsets() []int {
+	offsets := []int{}
+	for offset := 1; offset < state.NumPlayers; offset++ {
+		if state.isTargetable(state.getTargetIDFromOffset(offset)) {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+// isTargetable returns true if the player is in the game and isn't protected by a Handmaid.
+func (state Gamestate) isTargetable(player int) bool {
+	return !state.EliminatedPlayers[player] && !state.isProtected(player)
+}
+
+// Violation identifies a rule that an action would break.
+type Violation int
+
+const (
+	// GameAlreadyEnded means no more actions can be taken.
+	GameAlreadyEnded = Violation(iota)
+
+	// InvalidCard means the played card isn't a card that can be played.
+	InvalidCard
+
+	// MustPlayCountess means a card was played while keeping a card that must be played instead, like playing a
+	// King or Prince while keeping the Countess.
+	MustPlayCountess
+
+	// InvalidTarget means the target offset doesn't refer to a player who can be targeted by the card.
+	InvalidTarget
+
+	// ProtectedTarget means a player protected by a Handmaid was targeted when another target was available.
+	ProtectedTarget
+
+	// InvalidGuess means a Guard guess was not a card (other than the Guard) in the game.
+	InvalidGuess
+
+	// InvalidResolution means the action doesn't resolve the pending card (e.g. keeping a card that wasn't drawn by a Chancellor).
+	InvalidResolution
+
+	// MustTargetForced means the action didn't target the player chosen by a Sycophant.
+	MustTargetForced
+)
+
+var descriptionOfViolation = map[Violation]string{
+	GameAlreadyEnded:  "the game has already ended",
+	InvalidCard:       "the card cannot be played",
+	MustPlayCountess:  "the other card (e.g. the Countess with a King or Prince) must be played instead",
+	InvalidTarget:     "the target player cannot be targeted",
+	ProtectedTarget:   "the target player is protected by a Handmaid",
+	InvalidGuess:      "a Guard must guess a card other than Guard",
+	InvalidResolution: "the choice doesn't resolve the card that was played",
+	MustTargetForced:  "the player chosen by the Sycophant must be targeted",
+}
+
+func (v Violation) String() string {
+	return descriptionOfViolation[v]
+}
+
+// IllegalActionError is returned when an action breaks the rules.
+type IllegalActionError struct {
+	// Violation is the rule that was broken.
+	Violation Violation
+
+	// Action is the action that was attempted.
+	Action Action
+
+	// Card is the card the action would have played.
+	Card Card
+}
+
+func (err IllegalActionError) Error() string {
+	return fmt.Sprintf("illegal play of %s: %s", err.Card.String(), err.Violation.String())
+}
+
+// TryPlayCard takes the provided action if it is legal. Of course only the active player should call this at any time.
+// Unlike PlayCard, which eliminates a player for an illegal action, this leaves the state untouched and returns an
+// IllegalActionError describing the problem.
+func (state *Gamestate) TryPlayCard(action Action, r *rand.Rand) error {
+	if err := state.CheckAction(action); err != nil {
+		return err
+	}
+	state.PlayCard(action, r)
+	return nil
+}
+
+// CheckAction returns an IllegalActionError if the action breaks the rules, or nil if it can be played.
+// Unlike IsLegal, this ignores fields of the Action that are irrelevant to the card being played.
+func (state Gamestate) CheckAction(action Action) error {
+	card, other := state.ActivePlayerCard, state.CardInHand[state.ActivePlayer]
+	if !action.PlayRecent {
+		card, other = other, card
+	}
+	violation := func(v Violation) error {
+		return IllegalActionError{Violation: v, Action: action, Card: card}
+	}
+
+	if state.GameEnded {
+		return violation(GameAlreadyEnded)
+	}
+	if state.PendingCard != None {
+		if !state.IsLegal(action) {
+			return IllegalActionError{Violation: InvalidResolution, Action: action, Card: state.PendingCard}
+		}
+		return nil
+	}
+	cards := state.cards()
+	def := cards.Definition(card)
+	if def == nil {
+		return violation(InvalidCard)
+	}
+	if kept := cards.Definition(other); kept != nil && kept.MustBePlayedInsteadOf(card) {
+		return violation(MustPlayCountess)
+	}
+
+	blocked, v, ok := state.checkTargets(def.Targeting(), action)
+	if !ok {
+		return violation(v)
+	}
+	if !blocked && def.Targeting() == GuessOpponent && !state.isGuessable(def, card, action.SelectedCard) {
+		return violation(InvalidGuess)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegalActionsCountessWithKing(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = King
+	state.CardInHand[1] = Guard
+	state.ActivePlayerCard = Countess
+
+	assert.Equal(t, []Action{{PlayRecent: true}}, state.LegalActions())
+}
+
+func TestLegalActionsCountessWithPrince(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = Countess
+	state.CardInHand[1] = Guard
+	state.ActivePlayerCard = Prince
+
+	assert.Equal(t, []Action{{PlayRecent: false}}, state.LegalActions())
+}
+
+func TestLegalActionsGuard(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = Handmaid
+	state.CardInHand[1] = Guard
+	state.ActivePlayerCard = Guard
+
+	acts := state.LegalActions()
+	assert.Len(t, acts, 7+1)
+	assert.Contains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Princess})
+	assert.NotContains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Guard})
+	assert.Contains(t, acts, Action{PlayRecent: false})
+}
+
+func TestLegalActionsHandmaidBlocksTargets(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Guard
+	state.LastPlay[1] = Handmaid
+	state.ActivePlayerCard = Prince
+
+	acts := state.LegalActions()
+	assert.Equal(t, []Action{
+		{PlayRecent: true, TargetPlayerOffset: 0},
+		{PlayRecent: false, TargetPlayerOffset: 1},
+	}, acts)
+}
+
+func TestLegalActionsMultiplePlayers(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 4)
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = Guard
+	state.CardInHand[2] = None
+	state.CardInHand[3] = Guard
+	state.EliminatedPlayers[2] = true
+	state.LastPlay[3] = Handmaid
+	state.ActivePlayerCard = Countess
+
+	assert.Equal(t, []Action{
+		{PlayRecent: true},
+		{PlayRecent: false, TargetPlayerOffset: 1},
+	}, state.LegalActions())
+}
+
+func TestLegalActionsGameEnded(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.GameEnded = true
+	assert.Empty(t, state.LegalActions())
+}
+
+func TestIsLegal(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = King
+	state.CardInHand[1] = Guard
+	state.ActivePlayerCard = Priest
+
+	assert.True(t, state.IsLegal(Action{PlayRecent: false, TargetPlayerOffset: 1}))
+	assert.False(t, state.IsLegal(Action{PlayRecent: false, TargetPlayerOffset: 0}))
+}
+
+func TestTryPlayCardKeepingCountess(t *testing.T) {
+	r.Seed(0)
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = Prince
+	state.CardInHand[1] = Princess
+	state.ActivePlayerCard = Countess
+	before := state.Token()
+
+	err := state.TryPlayCard(Action{PlayRecent: false, TargetPlayerOffset: 1}, r)
+
+	assert.Equal(t, IllegalActionError{
+		Violation: MustPlayCountess,
+		Action:    Action{PlayRecent: false, TargetPlayerOffset: 1},
+		Card:      Prince,
+	}, err)
+	assert.False(t, state.GameEnded)
+	assert.False(t, state.LossWasStupid)
+	assert.Equal(t, before, state.Token())
+}
+
+func TestTryPlayCardViolations(t *testing.T) {
+	tests := []struct {
+		descr     string
+		action    Action
+		violation Violation
+	}{
+		{"Guard on self", Action{PlayRecent: true, TargetPlayerOffset: 0, SelectedCard: Priest}, InvalidTarget},
+		{"Guard out of range", Action{PlayRecent: true, TargetPlayerOffset: 2, SelectedCard: Priest}, InvalidTarget},
+		{"Guard guessing Guard", Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Guard}, InvalidGuess},
+		{"Guard guessing nothing", Action{PlayRecent: true, TargetPlayerOffset: 1}, InvalidGuess},
+		{"Baron on self", Action{PlayRecent: false, TargetPlayerOffset: 0}, InvalidTarget},
+	}
+	for _, test := range tests {
+		state := newGame(Deck{Guard: 4}, 2)
+		state.CardInHand[0] = Baron
+		state.CardInHand[1] = Priest
+		state.ActivePlayerCard = Guard
+
+		err := state.TryPlayCard(test.action, r)
+		if assert.IsType(t, IllegalActionError{}, err, test.descr) {
+			assert.Equal(t, test.violation, err.(IllegalActionError).Violation, test.descr)
+		}
+		assert.Equal(t, 0, state.ActivePlayer, test.descr)
+	}
+}
+
+func TestTryPlayCardProtectedTarget(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 2)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Priest
+	state.LastPlay[1] = Handmaid
+	state.ActivePlayerCard = Prince
+
+	err := state.TryPlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1}, r)
+	assert.Equal(t, ProtectedTarget, err.(IllegalActionError).Violation)
+
+	// The Guard has no target, so it can be played without effect
+	assert.NoError(t, state.TryPlayCard(Action{PlayRecent: false, TargetPlayerOffset: 1}, r))
+	assert.Equal(t, 1, state.ActivePlayer)
+}
+
+func TestLegalActionsAreAllowed(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		state, err := NewGame(2, r)
+		assert.NoError(t, err)
+		for !state.GameEnded {
+			acts := state.LegalActions()
+			for _, act := range acts {
+				assert.NoError(t, state.CheckAction(act))
+			}
+			assert.NoError(t, state.TryPlayCard(acts[r.Intn(len(acts))], r))
+		}
+	}
+}
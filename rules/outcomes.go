@@ -0,0 +1,83 @@
+package rules
+
+// Outcome is one of the states an action can lead to, and how likely it is.
+type Outcome struct {
+	Probability float64
+	Next        Gamestate
+}
+
+// Outcomes returns every state the action can lead to, with exact probabilities, instead of drawing cards at random.
+// The action is played once for each sequence of cards that can be drawn (e.g. by a Prince, and at the start of the
+// next player's turn). Copies of a card are interchangeable, so drawing either is a single outcome.
+// The probabilities add up to 1, and an action that draws nothing has a single outcome. The game isn't changed, and
+// the outcomes have no Source or Recording.
+func (state Gamestate) Outcomes(action Action) []Outcome {
+	outcomes := []Outcome{}
+	src := &chanceSource{}
+	for {
+		src.restart()
+		next := state.Copy()
+		next.Source = src
+		next.PlayCard(action, nil)
+		next.Source = nil
+		outcomes = append(outcomes, Outcome{Probability: src.probability, Next: next})
+		if !src.advance() {
+			return outcomes
+		}
+	}
+}
+
+// chanceSource draws the cards in path, and the first card left in the deck once path runs out, so that every
+// sequence of draws can be played in turn. It keeps the probability of the cards drawn since it was restarted.
+type chanceSource struct {
+	// path contains the cards to draw, in order.
+	path Stack
+
+	// decks contains the deck before each draw since the source was restarted.
+	decks []Deck
+
+	probability float64
+}
+
+func (cs *chanceSource) restart() {
+	cs.decks = cs.decks[:0]
+	cs.probability = 1
+}
+
+func (cs *chanceSource) Draw(deck *Deck) Card {
+	size := deck.Size()
+	if size == 0 {
+		return None
+	}
+	draw := len(cs.decks)
+	cs.decks = append(cs.decks, *deck)
+	if draw == len(cs.path) {
+		cs.path = append(cs.path, nextCard(*deck, None))
+	}
+	card := cs.path[draw]
+	cs.probability *= float64(deck[card]) / float64(size)
+	deck[card]--
+	return card
+}
+
+// advance changes the path to the next sequence of draws, and returns false once every sequence has been drawn.
+// The last draw that could have been a different card is changed, and every draw after it is forgotten.
+func (cs *chanceSource) advance() bool {
+	for draw := len(cs.decks) - 1; draw >= 0; draw-- {
+		if card := nextCard(cs.decks[draw], cs.path[draw]); card != None {
+			cs.path = append(cs.path[:draw], card)
+			return true
+		}
+	}
+	return false
+}
+
+// nextCard returns the first card left in the deck after the provided card, or None if there isn't one.
+func nextCard(deck Deck, after Card) Card {
+	for card := after + 1; card < numberOfCards; card++ {
+		if deck[card] > 0 {
+			return card
+		}
+	}
+	return None
+}
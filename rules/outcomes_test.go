@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcomesOfPrince(t *testing.T) {
+	state := newGame(Deck{
+		Guard:  4,
+		Priest: 2,
+		Baron:  2,
+	}, 2)
+	state.CardInHand[0] = Prince
+	state.CardInHand[1] = Countess
+	state.ActivePlayerCard = Guard
+	before := state.Token()
+
+	outcomes := state.Outcomes(Action{TargetPlayerOffset: 1})
+	assert.Equal(t, before, state.Token(), "The game isn't changed")
+
+	// The other player redraws, then draws again at the start of their turn
+	assert.Len(t, outcomes, 9)
+	probabilities := map[[2]Card]float64{}
+	for _, outcome := range outcomes {
+		assert.Equal(t, 1, outcome.Next.ActivePlayer)
+		assert.Nil(t, outcome.Next.Source)
+		probabilities[[2]Card{outcome.Next.CardInHand[1], outcome.Next.ActivePlayerCard}] += outcome.Probability
+	}
+	assert.InDelta(t, 4.0/8*3/7, probabilities[[2]Card{Guard, Guard}], 1e-9)
+	assert.InDelta(t, 2.0/8*1/7, probabilities[[2]Card{Priest, Priest}], 1e-9)
+	assert.InDelta(t, 2.0/8*2/7, probabilities[[2]Card{Baron, Priest}], 1e-9)
+}
+
+func TestOutcomesWithoutDraws(t *testing.T) {
+	state := newGame(Deck{Guard: 1}, 2)
+	state.CardInHand[0] = Handmaid
+	state.CardInHand[1] = Countess
+	state.ActivePlayerCard = Guard
+
+	// The face-down card can't be drawn, so the game ends
+	outcomes := state.Outcomes(Action{PlayRecent: false})
+	if assert.Len(t, outcomes, 1) {
+		assert.Equal(t, 1.0, outcomes[0].Probability)
+		assert.True(t, outcomes[0].Next.GameEnded)
+	}
+}
+
+func TestOutcomesAddUp(t *testing.T) {
+	for _, cards := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for i := 0; i < 5; i++ {
+			state, _ := NewGameWithCards(cards, cards.MinPlayers+i%(cards.MaxPlayers-cards.MinPlayers+1), r)
+			for !state.GameEnded {
+				acts := state.LegalActions()
+				action := acts[r.Intn(len(acts))]
+				total := 0.0
+				for _, outcome := range state.Outcomes(action) {
+					assert.True(t, outcome.Probability > 0)
+					assert.NoError(t, outcome.Next.Validate())
+					total += outcome.Probability
+				}
+				assert.True(t, math.Abs(total-1) < 1e-9, "The probabilities add up to %v", total)
+				state.PlayCard(action, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rules
+
+import "math/rand"
+
+// PremiumCards is the CardSet for the Premium edition of Love Letter, which supports 5 to 8 players.
+// It adds nine characters to the classic cards, and the Guard guesses a value instead of a card.
+var PremiumCards = newPremiumCards()
+
+func init() {
+	RegisterCardSet(PremiumCards)
+}
+
+func newPremiumCards() *CardSet {
+	cs := NewCardSet("premium", 5, 8)
+	cs.TokensToWin = map[int]int{5: 3, 6: 3, 7: 3, 8: 3}
+	cs.Register(Guard, premiumGuard{NewBaseCard("Guard", 1, 8, GuessOpponent)})
+	cs.Register(Priest, priest{NewBaseCard("Priest", 2, 2, TargetOpponent)})
+	cs.Register(Baron, baron{NewBaseCard("Baron", 3, 2, TargetOpponent)})
+	cs.Register(Handmaid, handmaid{NewBaseCard("Handmaid", 4, 2, NoTarget)})
+	cs.Register(Prince, prince{NewBaseCard("Prince", 5, 2, TargetAnyPlayer)})
+	cs.Register(King, king{NewBaseCard("King", 6, 1, TargetOpponent)})
+	cs.Register(Countess, countess{NewBaseCard("Countess", 7, 1, NoTarget)})
+	cs.Register(Princess, premiumPrincess{princess{NewBaseCard("Princess", 8, 1, NoTarget)}})
+	cs.Register(Jester, jester{NewBaseCard("Jester", 0, 1, TargetOpponent)})
+	cs.Register(Assassin, NewBaseCard("Assassin", 0, 1, NoTarget))
+	cs.Register(Cardinal, cardinal{NewBaseCard("Cardinal", 2, 2, TargetTwoPlayers)})
+	cs.Register(Baroness, baroness{NewBaseCard("Baroness", 3, 2, TargetOneOrTwoOpponents)})
+	cs.Register(Sycophant, sycophant{NewBaseCard("Sycophant", 4, 2, TargetAnyPlayer)})
+	cs.Register(Count, count{NewBaseCard("Count", 5, 2, NoTarget)})
+	cs.Register(Constable, constable{NewBaseCard("Constable", 6, 1, NoTarget)})
+	cs.Register(DowagerQueen, dowagerQueen{NewBaseCard("Dowager Queen", 7, 1, TargetOpponent)})
+	cs.Register(Bishop, bishop{NewBaseCard("Bishop", 9, 1, GuessOpponent)})
+	return cs
+}
+
+// valueGuesses returns a card for each value other than 1, which is how the Premium Guard and Bishop guess.
+func valueGuesses(cards *CardSet) []Card {
+	return cards.CardsWithValue(1)
+}
+
+type premiumGuard struct{ BaseCard }
+
+func (premiumGuard) Guesses(cards *CardSet) []Card { return valueGuesses(cards) }
+
+// Play eliminates the target if their card has the guessed value. If the target holds the Assassin, the active player
+// is eliminated instead, and the target discards the Assassin and draws a new card.
+func (premiumGuard) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	cards := state.cards()
+	targetCard := state.CardInHand[target]
+	if targetCard == Assassin {
+		state.ForceDiscard(target, r)
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByCardEffect)
+		return
+	}
+	correct := cards.Value(targetCard) == cards.Value(action.SelectedCard)
+	state.RecordEvent(GuardGuess{Player: state.ActivePlayer, Target: target, Guess: action.SelectedCard, Correct: correct})
+	if correct {
+		state.eliminatePlayer(target, EliminatedByGuess)
+	}
+}
+
+type premiumPrincess struct{ princess }
+
+// ModifyValue makes the Princess beat the Bishop at the end of the game, even though the Bishop has a higher value.
+func (premiumPrincess) ModifyValue(state Gamestate, player int, value int) int {
+	if state.CardInHand[player] != Princess {
+		return value
+	}
+	for pid, card := range state.CardInHand {
+		if card == Bishop && !state.EliminatedPlayers[pid] {
+			return state.cards().Value(Bishop) + 1
+		}
+	}
+	return value
+}
+
+type jester struct{ BaseCard }
+
+// Play gives the target a Jester token from the active player.
+func (jester) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	if len(state.JesterTokens) != state.NumPlayers {
+		state.JesterTokens = make([]int, state.NumPlayers)
+		for i := range state.JesterTokens {
+			state.JesterTokens[i] = -1
+		}
+	}
+	state.JesterTokens[target] = state.ActivePlayer
+}
+
+// Bonus awards a token to each player who gave a winner a Jester token.
+func (jester) Bonus(state Gamestate) []int {
+	bonus := make([]int, state.NumPlayers)
+	for _, winner := range state.Winners {
+		if winner < len(state.JesterTokens) && state.JesterTokens[winner] >= 0 {
+			bonus[state.JesterTokens[winner]]++
+		}
+	}
+	return bonus
+}
+
+type cardinal struct{ BaseCard }
+
+// Play swaps the hands of the two targets. The active player then looks at the first target's new card, or the
+// second target's if the active player was the first target.
+func (cardinal) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	second := state.getTargetIDFromOffset(action.SecondTargetOffset)
+	state.swapHands(target, second)
+	if target == state.ActivePlayer {
+		target = second
+	}
+	state.reveal(target, state.ActivePlayer)
+}
+
+type baroness struct{ BaseCard }
+
+// Play lets the active player look at the hands of one or two opponents.
+func (baroness) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	state.reveal(target, state.ActivePlayer)
+	if action.SecondTargetOffset != 0 {
+		state.reveal(state.getTargetIDFromOffset(action.SecondTargetOffset), state.ActivePlayer)
+	}
+}
+
+type sycophant struct{ BaseCard }
+
+// Play forces the next card played to target the chosen player, if it can.
+func (sycophant) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	state.ForcedTarget = target
+}
+
+type count struct{ BaseCard }
+
+// ModifyValue adds 1 to the player's hand for each Count in their discards.
+func (count) ModifyValue(state Gamestate, player int, value int) int {
+	for _, card := range state.Discards[player] {
+		if card == Count {
+			value++
+		}
+	}
+	return value
+}
+
+type constable struct{ BaseCard }
+
+// KnockedOut awards a token to the eliminated player.
+func (constable) KnockedOut(state *Gamestate, player int) {
+	state.AwardToken(player)
+}
+
+type dowagerQueen struct{ BaseCard }
+
+// Play compares hands like a Baron, but the player with the higher value is eliminated.
+func (dowagerQueen) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	targetValue, activeValue := state.compareHands(target)
+	switch {
+	case targetValue > activeValue:
+		state.eliminatePlayer(target, EliminatedByComparison)
+	case targetValue < activeValue:
+		state.eliminatePlayer(state.ActivePlayer, EliminatedByComparison)
+	}
+}
+
+type bishop struct{ BaseCard }
+
+func (bishop) Guesses(cards *CardSet) []Card { return valueGuesses(cards) }
+
+// Play awards a token to the active player if the target's card has the guessed value.
+// The rules let the target choose to discard their card and draw a new one. Since everyone may now know their card,
+// the target always does so, unless discarding it would eliminate them (like the Princess).
+func (bishop) Play(state *Gamestate, action Action, target int, r *rand.Rand) {
+	cards := state.cards()
+	targetCard := state.CardInHand[target]
+	correct := cards.Value(targetCard) == cards.Value(action.SelectedCard)
+	state.RecordEvent(GuardGuess{Player: state.ActivePlayer, Target: target, Guess: action.SelectedCard, Correct: correct})
+	if !correct {
+		return
+	}
+	state.AwardToken(state.ActivePlayer)
+	if def := cards.Definition(targetCard); def == nil || !def.EliminatesWhenDiscarded() {
+		state.ForceDiscard(target, r)
+	}
+}
@@ -0,0 +1,270 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPremiumGame(deck Deck, playerCount int) Gamestate {
+	state := newGame(deck, playerCount)
+	state.CardSet = PremiumCards
+	return state
+}
+
+func TestPremiumDeck(t *testing.T) {
+	deck := PremiumCards.Deck()
+	assert.Equal(t, 32, deck.Size())
+	assert.Equal(t, 8, deck[Guard])
+	assert.Equal(t, 2, deck[Cardinal])
+	assert.Equal(t, 1, deck[Bishop])
+	assert.Equal(t, 9, PremiumCards.Value(Bishop))
+	assert.Equal(t, 0, PremiumCards.Value(Assassin))
+	assert.Equal(t, "Dowager Queen", DowagerQueen.String())
+}
+
+func TestPremiumPlayerCounts(t *testing.T) {
+	for _, numPlayers := range []int{5, 8} {
+		state, err := NewGame(numPlayers, r)
+		assert.NoError(t, err)
+		assert.Equal(t, PremiumCards, state.CardSet)
+		assert.Equal(t, 32-numPlayers-1, state.Deck.Size())
+		assert.Empty(t, state.Faceup)
+	}
+
+	_, err := NewGame(9, r)
+	assert.Error(t, err)
+	_, err = NewGameWithCards(PremiumCards, 4, r)
+	assert.Error(t, err)
+	_, err = NewGameWithCards(ClassicCards, 5, r)
+	assert.Error(t, err)
+}
+
+func TestPremiumGuardGuessesValues(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Cardinal
+	state.ActivePlayerCard = Guard
+
+	acts := state.LegalActions()
+	assert.Contains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Priest})
+	assert.Contains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Bishop})
+	assert.NotContains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Cardinal})
+	assert.Len(t, acts, 2*4*9)
+
+	// A Priest has the same value as the Cardinal
+	assert.NoError(t, state.TryPlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Priest}, r))
+	assert.True(t, state.EliminatedPlayers[1])
+}
+
+func TestPremiumGuardAgainstAssassin(t *testing.T) {
+	r.Seed(0)
+	state := newPremiumGame(Deck{Priest: 4}, 5)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Assassin
+	state.ActivePlayerCard = Guard
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Baron}, r)
+
+	assert.True(t, state.EliminatedPlayers[0], "The Guard's player is knocked out")
+	assert.False(t, state.EliminatedPlayers[1])
+	assert.Equal(t, Stack{Assassin}, state.Discards[1])
+	assert.Equal(t, Priest, state.CardInHand[1])
+}
+
+func TestJesterBonus(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[2] = Princess
+	state.ActivePlayerCard = Jester
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 2}, r)
+	assert.Equal(t, []int{-1, -1, 0, -1, -1}, state.JesterTokens)
+
+	state.Deck = Deck{Guard: 1}
+	state.PlayCard(Action{PlayRecent: true}, r)
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 2, state.Winner)
+	assert.Equal(t, []int{1, 0, 0, 0, 0}, state.BonusTokens)
+}
+
+func TestCardinalSwapsOtherPlayers(t *testing.T) {
+	r.Seed(0)
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Baron
+	state.CardInHand[3] = King
+	state.ActivePlayerCard = Cardinal
+
+	assert.NoError(t, state.TryPlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SecondTargetOffset: 3}, r))
+
+	assert.Equal(t, King, state.CardInHand[1])
+	assert.Equal(t, Baron, state.CardInHand[3])
+	assert.Equal(t, King, state.KnownCards[1][0], "The Cardinal's player looks at the first target's hand")
+	assert.Equal(t, King, state.KnownCards[1][3], "Each target knows the card they gave away")
+	assert.Equal(t, Baron, state.KnownCards[3][1])
+}
+
+func TestCardinalTargetsTwoDifferentPlayers(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.ActivePlayerCard = Cardinal
+
+	err := state.CheckAction(Action{PlayRecent: true, TargetPlayerOffset: 2, SecondTargetOffset: 2})
+	assert.Equal(t, InvalidTarget, err.(IllegalActionError).Violation)
+	assert.NoError(t, state.CheckAction(Action{PlayRecent: true, TargetPlayerOffset: 0, SecondTargetOffset: 2}))
+}
+
+func TestBaronessLooksAtTwoHands(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[2] = Prince
+	state.CardInHand[4] = Countess
+	state.ActivePlayerCard = Baroness
+
+	acts := state.LegalActions()
+	assert.Contains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 2})
+	assert.Contains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 2, SecondTargetOffset: 4})
+	assert.NotContains(t, acts, Action{PlayRecent: true, TargetPlayerOffset: 4, SecondTargetOffset: 2})
+
+	assert.NoError(t, state.TryPlayCard(Action{PlayRecent: true, TargetPlayerOffset: 4, SecondTargetOffset: 2}, r))
+	assert.Equal(t, Prince, state.KnownCards[2][0])
+	assert.Equal(t, Countess, state.KnownCards[4][0])
+}
+
+func TestBaronessCantTargetSelf(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.ActivePlayerCard = Baroness
+
+	err := state.CheckAction(Action{PlayRecent: true, TargetPlayerOffset: 0, SecondTargetOffset: 2})
+	assert.Equal(t, InvalidTarget, err.(IllegalActionError).Violation)
+}
+
+func TestSycophantForcesTarget(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Priest
+	state.ActivePlayerCard = Sycophant
+
+	assert.NoError(t, state.TryPlayCard(Action{PlayRecent: true, TargetPlayerOffset: 3}, r))
+	assert.Equal(t, 3, state.ForcedTarget)
+
+	state.ActivePlayerCard = Baron
+	assert.Equal(t, []Action{
+		{PlayRecent: true, TargetPlayerOffset: 2},
+		{PlayRecent: false, TargetPlayerOffset: 2},
+	}, state.LegalActions())
+	err := state.TryPlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1}, r)
+	assert.Equal(t, MustTargetForced, err.(IllegalActionError).Violation)
+
+	assert.NoError(t, state.TryPlayCard(Action{PlayRecent: false, TargetPlayerOffset: 2}, r))
+	assert.Equal(t, -1, state.ForcedTarget, "Only the next card played is forced")
+}
+
+func TestCountAddsToHandValue(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 1}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = King
+	state.CardInHand[2] = Prince
+	state.Discards[2] = Stack{Count, Count}
+	state.ActivePlayerCard = Handmaid
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 2, state.Winner, "A Prince with two Counts beats a King")
+}
+
+func TestPrincessBeatsBishop(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 1}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Bishop
+	state.CardInHand[3] = Princess
+	state.ActivePlayerCard = Handmaid
+
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.True(t, state.GameEnded)
+	assert.Equal(t, 3, state.Winner)
+}
+
+func TestConstableKnockoutBonus(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Prince
+	state.Discards[1] = Stack{Constable}
+	state.ActivePlayerCard = Guard
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Prince}, r)
+
+	assert.True(t, state.EliminatedPlayers[1])
+	assert.Equal(t, []int{0, 1, 0, 0, 0}, state.BonusTokens)
+}
+
+func TestDowagerQueenEliminatesHigher(t *testing.T) {
+	state := newPremiumGame(Deck{Guard: 4}, 5)
+	state.CardInHand[0] = Priest
+	state.CardInHand[1] = King
+	state.ActivePlayerCard = DowagerQueen
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1}, r)
+
+	assert.True(t, state.EliminatedPlayers[1])
+	assert.False(t, state.EliminatedPlayers[0])
+}
+
+func TestBishopCorrectGuess(t *testing.T) {
+	r.Seed(0)
+	state := newPremiumGame(Deck{Priest: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Count
+	state.ActivePlayerCard = Bishop
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Prince}, r)
+
+	assert.Equal(t, []int{1, 0, 0, 0, 0}, state.BonusTokens)
+	assert.Equal(t, Stack{Count}, state.Discards[1], "The target discards their card")
+	assert.Equal(t, Priest, state.CardInHand[1])
+	assert.False(t, state.EliminatedPlayers[1])
+}
+
+func TestBishopDoesNotDiscardPrincess(t *testing.T) {
+	state := newPremiumGame(Deck{Priest: 4}, 5)
+	state.CardInHand[0] = Guard
+	state.CardInHand[1] = Princess
+	state.ActivePlayerCard = Bishop
+
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: Princess}, r)
+
+	assert.Equal(t, []int{1, 0, 0, 0, 0}, state.BonusTokens)
+	assert.Equal(t, Princess, state.CardInHand[1])
+	assert.False(t, state.EliminatedPlayers[1])
+}
+
+func TestPremiumGamesComplete(t *testing.T) {
+	for numPlayers := 5; numPlayers <= 8; numPlayers++ {
+		for i := 0; i < 100; i++ {
+			state, err := NewGame(numPlayers, r)
+			assert.NoError(t, err)
+			for !state.GameEnded {
+				assert.False(t, state.EliminatedPlayers[state.ActivePlayer], "Eliminated players don't take turns")
+				acts := state.LegalActions()
+				for _, act := range acts {
+					assert.NoError(t, state.CheckAction(act))
+				}
+				assert.NoError(t, state.TryPlayCard(acts[r.Intn(len(acts))], r))
+			}
+			assert.False(t, state.EliminatedPlayers[state.Winner], "The winner was not eliminated")
+
+			// Every card is accounted for
+			cards := state.AllDiscards()
+			cards.AddStack(state.CardInHand)
+			for i := range cards {
+				cards[i] += state.Deck[i]
+			}
+			cards[None] = 0
+			assert.Equal(t, PremiumCards.Deck(), cards)
+		}
+	}
+}
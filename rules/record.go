@@ -0,0 +1,122 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// GameRecord captures everything needed to replay a game exactly: the initial deal, every action, and every card
+// drawn at random. It can be serialized to JSON.
+type GameRecord struct {
+	// CardSet is the name of the registered CardSet used by the game.
+	CardSet string
+
+	NumPlayers int
+
+	Deal
+
+	// Turns contains each action in the order it was taken.
+	Turns []Turn
+}
+
+// Deal contains the cards dealt at the start of a game.
+type Deal struct {
+	Faceup           Stack
+	Hands            Stack
+	ActivePlayer     int
+	ActivePlayerCard Card
+}
+
+// Turn is an action taken by a player, along with the cards drawn at random while it was applied.
+// Cards drawn from the bottom of the deck (e.g. after a Chancellor) aren't random, so they aren't included.
+type Turn struct {
+	Player int
+	Action Action
+	Draws  Stack
+}
+
+// StartRecording records the game from now on in a new GameRecord, which is returned and set as the Recording.
+// It should be called right after the game is dealt, since the deal is taken from the current state.
+func (state *Gamestate) StartRecording() (*GameRecord, error) {
+	for _, discards := range state.Discards {
+		if len(discards) > 0 {
+			return nil, errors.New("Games can only be recorded from the start")
+		}
+	}
+	state.Recording = &GameRecord{
+		CardSet:    state.cards().Name,
+		NumPlayers: state.NumPlayers,
+		Deal: Deal{
+			Faceup:           state.Faceup.Copy(),
+			Hands:            state.CardInHand.Copy(),
+			ActivePlayer:     state.ActivePlayer,
+			ActivePlayerCard: state.ActivePlayerCard,
+		},
+		Turns: []Turn{},
+	}
+	return state.Recording, nil
+}
+
+// Replay plays the recorded game again. It returns the state after the deal, followed by the state after each turn.
+func Replay(record GameRecord) ([]Gamestate, error) {
+	cards, err := CardSetNamed(record.CardSet)
+	if err != nil {
+		return nil, err
+	}
+	if len(record.Hands) != record.NumPlayers {
+		return nil, fmt.Errorf("The deal has %d hands for %d players", len(record.Hands), record.NumPlayers)
+	}
+
+	state := newGame(cards.Deck(), record.NumPlayers)
+	state.CardSet = cards
+	dealt := NewStackedSource(append(append(record.Faceup.Copy(), record.Hands...), record.ActivePlayerCard)...)
+	for range dealt.Cards {
+		dealt.Draw(&state.Deck)
+	}
+	if err := dealt.Err(); err != nil {
+		return nil, fmt.Errorf("The deal can't be replayed: %v", err)
+	}
+	state.Faceup = record.Faceup.Copy()
+	state.CardInHand = record.Hands.Copy()
+	state.ActivePlayer = record.ActivePlayer
+	state.ActivePlayerCard = record.ActivePlayerCard
+	state.EventLog = newEventLog(record.NumPlayers)
+
+	states := []Gamestate{state.Copy()}
+	for i, turn := range record.Turns {
+		if state.GameEnded {
+			return states, fmt.Errorf("Turn %d was taken after the game ended", i)
+		}
+		if turn.Player != state.ActivePlayer {
+			return states, fmt.Errorf("Turn %d was taken by player %d, but player %d is active", i, turn.Player, state.ActivePlayer)
+		}
+		draws := NewStackedSource(turn.Draws...)
+		state.Source = draws
+		state.PlayCard(turn.Action, nil)
+		if err := draws.Err(); err != nil {
+			return states, fmt.Errorf("Turn %d can't be replayed: %v", i, err)
+		}
+		if len(draws.Cards) > 0 {
+			return states, fmt.Errorf("Turn %d recorded %d more draws than were made", i, len(draws.Cards))
+		}
+		states = append(states, state.Copy())
+	}
+	return states, nil
+}
+
+// drawChance draws a card from the Deck using the Source, or at random if there is none.
+// The card is added to the Recording.
+func (state *Gamestate) drawChance(r *rand.Rand) Card {
+	var card Card
+	if state.Source != nil {
+		card = state.Source.Draw(&state.Deck)
+	} else {
+		card = state.Deck.Draw(r)
+	}
+	if state.Recording != nil && len(state.Recording.Turns) > 0 {
+		turn := &state.Recording.Turns[len(state.Recording.Turns)-1]
+		turn.Draws = append(turn.Draws, card)
+	}
+	return card
+}
@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayReproducesGames(t *testing.T) {
+	for _, cards := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for i := 0; i < 20; i++ {
+			state, err := NewGameWithCards(cards, cards.MaxPlayers, r)
+			assert.NoError(t, err)
+			record, err := state.StartRecording()
+			assert.NoError(t, err)
+
+			states := []Gamestate{state.Copy()}
+			for !state.GameEnded {
+				acts := state.LegalActions()
+				state.PlayCard(acts[r.Intn(len(acts))], r)
+				states = append(states, state.Copy())
+			}
+
+			// The record survives a round trip through JSON
+			data, err := json.Marshal(record)
+			assert.NoError(t, err)
+			var decoded GameRecord
+			assert.NoError(t, json.Unmarshal(data, &decoded))
+
+			replayed, err := Replay(decoded)
+			assert.NoError(t, err)
+			assert.Equal(t, states, replayed, cards.Name)
+		}
+	}
+}
+
+func TestReplayRejectsBadRecords(t *testing.T) {
+	r.Seed(0)
+	state, _ := NewGame(2, r)
+	record, _ := state.StartRecording()
+	acts := state.LegalActions()
+	state.PlayCard(acts[0], r)
+
+	tooFew := *record
+	tooFew.Turns = []Turn{{Player: 0, Action: acts[0]}}
+	_, err := Replay(tooFew)
+	assert.Error(t, err)
+
+	wrongPlayer := *record
+	wrongPlayer.Turns = []Turn{{Player: 1, Action: acts[0], Draws: record.Turns[0].Draws}}
+	_, err = Replay(wrongPlayer)
+	assert.Error(t, err)
+
+	unknown := *record
+	unknown.CardSet = "unknown"
+	_, err = Replay(unknown)
+	assert.Error(t, err)
+}
+
+func TestStartRecordingOnlyAtStart(t *testing.T) {
+	state, _ := NewGame(2, r)
+	state.Discards[0] = Stack{Guard}
+	_, err := state.StartRecording()
+	assert.Error(t, err)
+}
@@ -0,0 +1,363 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseScenario returns the game described by a scenario, which is a short text format for writing positions by hand
+// (e.g. in tests, puzzles and bug reports). Sections are separated by semicolons, for example:
+//
+//	cards=classic; active=0; p0: hand=Guard drawn=Prince discards=Priest,Handmaid; p1: hand=Princess; faceup=Baron
+//
+// Each player has a section with the following keys, which can be left out if they're empty:
+//   - hand: the player's card. Eliminated players have no hand, and are marked with "out" instead.
+//   - drawn: the active player's second card.
+//   - discards: the cards the player has discarded, in order. The last one is their LastPlay.
+//   - last: the player's LastPlay, if it isn't their last discard (e.g. a Prince made them discard), or None.
+//   - knows: the cards the player knows other players have, e.g. "knows=p1:King".
+//   - pending and draws: a card the active player played that still needs an action, and the cards it drew.
+//
+// The other sections are cards (the name of the CardSet, "classic" by default), active (the active player, 0 by
+// default), forced (the player chosen by a Sycophant), faceup, bottom (the bottom of the deck) and deck. If the deck
+// is left out, it contains every card that isn't anywhere else. Cards are separated by commas, and "Guard*3" is three
+// Guards. Scenarios describe games that haven't ended, and the game is returned only if it's valid.
+func ParseScenario(text string) (Gamestate, error) {
+	cards := ClassicCards
+	active, forced := 0, -1
+	var faceup, bottom Stack
+	var deck *Deck
+	players := map[int][]string{}
+
+	for _, section := range strings.Split(text, ";") {
+		section = strings.TrimSpace(section)
+		if section == "" {
+			continue
+		}
+		if colon := strings.Index(section, ":"); colon >= 0 && !strings.Contains(section[:colon], "=") {
+			pid, err := parseScenarioPlayer(section[:colon])
+			if err != nil {
+				return Gamestate{}, err
+			}
+			if _, ok := players[pid]; ok {
+				return Gamestate{}, fmt.Errorf("Player %d is in the scenario twice", pid)
+			}
+			players[pid] = strings.Fields(section[colon+1:])
+			continue
+		}
+
+		key, value, err := splitScenarioField(section)
+		if err != nil {
+			return Gamestate{}, err
+		}
+		switch key {
+		case "cards":
+			cards, err = CardSetNamed(value)
+		case "active":
+			active, err = strconv.Atoi(value)
+		case "forced":
+			forced, err = parseScenarioPlayer(value)
+		case "faceup":
+			faceup, err = parseScenarioCards(value)
+		case "bottom":
+			bottom, err = parseScenarioCards(value)
+		case "deck":
+			var stack Stack
+			stack, err = parseScenarioCards(value)
+			d := stack.AsDeck()
+			deck = &d
+		default:
+			err = fmt.Errorf("The scenario has an unknown section '%s'", key)
+		}
+		if err != nil {
+			return Gamestate{}, err
+		}
+	}
+
+	numPlayers := len(players)
+	if numPlayers < cards.MinPlayers || numPlayers > cards.MaxPlayers {
+		return Gamestate{}, fmt.Errorf("Only games with %d to %d players are supported by the %s cards", cards.MinPlayers, cards.MaxPlayers, cards.Name)
+	}
+	state := newGame(Deck{}, numPlayers)
+	state.CardSet = cards
+	state.EventLog = newEventLog(numPlayers)
+	state.Faceup = faceup.Copy()
+	state.DeckBottom = bottom.Copy()
+	state.ForcedTarget = forced
+	if active < 0 || active >= numPlayers {
+		return Gamestate{}, fmt.Errorf("The active player %d isn't in the scenario", active)
+	}
+	state.ActivePlayer = active
+
+	for pid := 0; pid < numPlayers; pid++ {
+		fields, ok := players[pid]
+		if !ok {
+			return Gamestate{}, fmt.Errorf("Player %d is missing from the scenario", pid)
+		}
+		if err := state.parseScenarioFields(pid, fields); err != nil {
+			return Gamestate{}, err
+		}
+	}
+
+	if deck != nil {
+		state.Deck = *deck
+	} else {
+		state.Deck = cards.Deck()
+		found := state.AllDiscards()
+		found.AddStack(state.DeckBottom)
+		found.AddStack(state.CardInHand)
+		found.AddStack(state.PendingDraws)
+		if state.PendingCard == None {
+			found.AddStack(Stack{state.ActivePlayerCard})
+		}
+		for card, count := range found {
+			if Card(card) != None {
+				state.Deck[card] -= count
+			}
+		}
+		for card, count := range state.Deck {
+			if count < 0 {
+				return Gamestate{}, fmt.Errorf("The scenario has %d too many of card %d (%s)", -count, card, Card(card))
+			}
+		}
+	}
+
+	return state, state.Validate()
+}
+
+// parseScenarioFields sets the player's cards from the fields in their section.
+func (state *Gamestate) parseScenarioFields(pid int, fields []string) error {
+	for _, field := range fields {
+		if field == "out" {
+			state.EliminatedPlayers[pid] = true
+			continue
+		}
+		key, value, err := splitScenarioField(field)
+		if err != nil {
+			return err
+		}
+		if key == "last" {
+			if err := state.parseScenarioLastPlay(pid, value); err != nil {
+				return err
+			}
+			continue
+		}
+		var stack Stack
+		if key != "knows" {
+			stack, err = parseScenarioCards(value)
+			if err != nil {
+				return err
+			}
+		}
+		switch key {
+		case "hand", "drawn", "pending":
+			if len(stack) != 1 {
+				return fmt.Errorf("Player %d's %s is '%s', but it should be one card", pid, key, value)
+			}
+			switch key {
+			case "hand":
+				state.CardInHand[pid] = stack[0]
+			case "drawn":
+				state.ActivePlayerCard = stack[0]
+			case "pending":
+				// The pending card was the active player's second card, and it's already in their discards
+				state.PendingCard = stack[0]
+				state.ActivePlayerCard = stack[0]
+			}
+		case "discards":
+			state.Discards[pid] = stack
+			if len(stack) > 0 {
+				state.LastPlay[pid] = stack[len(stack)-1]
+			}
+		case "draws":
+			state.PendingDraws = stack
+		case "knows":
+			err = state.parseScenarioKnowledge(pid, value)
+		default:
+			err = fmt.Errorf("Player %d has an unknown key '%s'", pid, key)
+		}
+		if err != nil {
+			return err
+		}
+		if (key == "drawn" || key == "pending" || key == "draws") && pid != state.ActivePlayer {
+			return fmt.Errorf("Player %d has a card that is %s, but isn't the active player", pid, key)
+		}
+	}
+	return nil
+}
+
+// parseScenarioLastPlay sets the player's LastPlay, which can be None.
+func (state *Gamestate) parseScenarioLastPlay(pid int, value string) error {
+	if strings.EqualFold(value, "None") {
+		state.LastPlay[pid] = None
+		return nil
+	}
+	card := scenarioCard(value)
+	if card == None {
+		return fmt.Errorf("The scenario has an unknown card '%s'", value)
+	}
+	state.LastPlay[pid] = card
+	return nil
+}
+
+// parseScenarioKnowledge reads a list like "p1:King,p2:Guard" into the cards the player knows.
+func (state *Gamestate) parseScenarioKnowledge(knower int, value string) error {
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("Player %d knows '%s', which should be like 'p1:King'", knower, item)
+		}
+		about, err := parseScenarioPlayer(parts[0])
+		if err != nil {
+			return err
+		}
+		if about >= state.NumPlayers {
+			return fmt.Errorf("Player %d knows about player %d, who isn't in the scenario", knower, about)
+		}
+		card := scenarioCard(parts[1])
+		if card == None {
+			return fmt.Errorf("The scenario has an unknown card '%s'", parts[1])
+		}
+		state.KnownCards[about][knower] = card
+	}
+	return nil
+}
+
+// splitScenarioField splits "key=value" into the key and value.
+func splitScenarioField(field string) (string, string, error) {
+	parts := strings.SplitN(field, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("The scenario has '%s', which should be like 'key=value'", field)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// parseScenarioPlayer reads a player like "p1".
+func parseScenarioPlayer(str string) (int, error) {
+	str = strings.TrimSpace(str)
+	pid, err := strconv.Atoi(strings.TrimPrefix(str, "p"))
+	if !strings.HasPrefix(str, "p") || err != nil || pid < 0 {
+		return 0, fmt.Errorf("The scenario has '%s', which should be a player like 'p1'", str)
+	}
+	return pid, nil
+}
+
+// parseScenarioCards reads a list of cards like "Guard*2,Priest".
+func parseScenarioCards(value string) (Stack, error) {
+	stack := Stack{}
+	if value == "" {
+		return stack, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		count := 1
+		if star := strings.Index(item, "*"); star >= 0 {
+			var err error
+			count, err = strconv.Atoi(item[star+1:])
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("The scenario has '%s', which isn't a number of cards", item)
+			}
+			item = item[:star]
+		}
+		card := scenarioCard(item)
+		if card == None {
+			return nil, fmt.Errorf("The scenario has an unknown card '%s'", item)
+		}
+		for i := 0; i < count; i++ {
+			stack = append(stack, card)
+		}
+	}
+	return stack, nil
+}
+
+// scenarioCard returns the card with the name, ignoring case and spaces (so "DowagerQueen" is the Dowager Queen).
+func scenarioCard(name string) Card {
+	for card := range nameOfCard {
+		if strings.EqualFold(scenarioName(card), strings.Replace(name, " ", "", -1)) {
+			return card
+		}
+	}
+	return None
+}
+
+// scenarioName returns the card's name without spaces, so it can be used in a scenario.
+func scenarioName(card Card) string {
+	return strings.Replace(card.String(), " ", "", -1)
+}
+
+// Scenario returns the game in the format read by ParseScenario. Every section is included, so the deck is always
+// listed. Things a scenario can't describe, like the event log and any bonus tokens, are left out.
+func (state Gamestate) Scenario() string {
+	sections := []string{
+		"cards=" + state.cards().Name,
+		"active=" + strconv.Itoa(state.ActivePlayer),
+	}
+	if state.ForcedTarget >= 0 {
+		sections = append(sections, "forced=p"+strconv.Itoa(state.ForcedTarget))
+	}
+
+	for pid := 0; pid < state.NumPlayers; pid++ {
+		fields := []string{}
+		if state.EliminatedPlayers[pid] {
+			fields = append(fields, "out")
+		} else {
+			fields = append(fields, "hand="+scenarioName(state.CardInHand[pid]))
+		}
+		if pid == state.ActivePlayer && !state.GameEnded {
+			if state.PendingCard != None {
+				fields = append(fields, "pending="+scenarioName(state.PendingCard))
+				fields = append(fields, "draws="+scenarioCards(state.PendingDraws))
+			} else {
+				fields = append(fields, "drawn="+scenarioName(state.ActivePlayerCard))
+			}
+		}
+		last := None
+		if discards := state.Discards[pid]; len(discards) > 0 {
+			fields = append(fields, "discards="+scenarioCards(discards))
+			last = discards[len(discards)-1]
+		}
+		if state.LastPlay[pid] != last {
+			if state.LastPlay[pid] == None {
+				fields = append(fields, "last=None")
+			} else {
+				fields = append(fields, "last="+scenarioName(state.LastPlay[pid]))
+			}
+		}
+		known := []string{}
+		for about, knowers := range state.KnownCards {
+			if knowers[pid] != None {
+				known = append(known, fmt.Sprintf("p%d:%s", about, scenarioName(knowers[pid])))
+			}
+		}
+		if len(known) > 0 {
+			fields = append(fields, "knows="+strings.Join(known, ","))
+		}
+		sections = append(sections, fmt.Sprintf("p%d: %s", pid, strings.Join(fields, " ")))
+	}
+
+	if len(state.Faceup) > 0 {
+		sections = append(sections, "faceup="+scenarioCards(state.Faceup))
+	}
+	if len(state.DeckBottom) > 0 {
+		sections = append(sections, "bottom="+scenarioCards(state.DeckBottom))
+	}
+	deck := []string{}
+	for card, count := range state.Deck {
+		if count == 1 {
+			deck = append(deck, scenarioName(Card(card)))
+		} else if count > 1 {
+			deck = append(deck, fmt.Sprintf("%s*%d", scenarioName(Card(card)), count))
+		}
+	}
+	sections = append(sections, "deck="+strings.Join(deck, ","))
+	return strings.Join(sections, "; ")
+}
+
+// scenarioCards returns the cards as a comma-separated list.
+func scenarioCards(stack Stack) string {
+	names := make([]string, len(stack))
+	for i, card := range stack {
+		names[i] = scenarioName(card)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScenario(t *testing.T) {
+	state, err := ParseScenario("p0: hand=Guard drawn=Prince discards=Priest,Handmaid; p1: hand=Princess knows=p0:Guard; faceup=Baron,Guard,Guard")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, state.NumPlayers)
+	assert.Equal(t, ClassicCards, state.CardSet)
+	assert.Equal(t, Stack{Guard, Princess}, state.CardInHand)
+	assert.Equal(t, Prince, state.ActivePlayerCard)
+	assert.Equal(t, Stack{Priest, Handmaid}, state.Discards[0])
+	assert.Equal(t, Handmaid, state.LastPlay[0])
+	assert.Equal(t, Guard, state.KnownCards[0][1])
+	assert.Equal(t, Stack{Baron, Guard, Guard}, state.Faceup)
+
+	// The rest of the cards are in the deck
+	assert.Equal(t, 16-8, state.Deck.Size())
+	assert.Equal(t, 2, state.Deck[Guard])
+	assert.Equal(t, "cards=classic; active=0; p0: hand=Guard drawn=Prince discards=Priest,Handmaid; p1: hand=Princess knows=p0:Guard; faceup=Baron,Guard,Guard; deck=Guard*2,Priest,Baron,Handmaid,Prince,King,Countess", state.Scenario())
+}
+
+func TestScenarioRules(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		scenario string
+		action   Action
+		expected string
+	}{
+		{
+			name:     "Prince on Princess",
+			scenario: "p0: hand=Prince drawn=Guard; p1: hand=Princess",
+			action:   Action{PlayRecent: false, TargetPlayerOffset: 1},
+			expected: "cards=classic; active=0; p0: hand=Guard discards=Prince; p1: out discards=Princess last=None; deck=Guard*4,Priest*2,Baron*2,Handmaid*2,Prince,King,Countess",
+		},
+		{
+			name:     "Baron loses",
+			scenario: "active=1; p0: hand=King; p1: hand=Baron drawn=Guard",
+			action:   Action{PlayRecent: false, TargetPlayerOffset: 1},
+			expected: "cards=classic; active=1; p0: hand=King; p1: out discards=Baron,Guard last=Baron; deck=Guard*4,Priest*2,Baron,Handmaid*2,Prince*2,Countess,Princess",
+		},
+		{
+			name:     "Chancellor",
+			scenario: "cards=2019; p0: hand=Spy drawn=Chancellor; p1: hand=Guard; p2: hand=Priest",
+			action:   Action{PlayRecent: true},
+			expected: "cards=2019; active=0; p0: hand=Spy pending=Chancellor draws=Baron,Handmaid discards=Chancellor; p1: hand=Guard; p2: hand=Priest; deck=Guard*5,Priest,Baron,Handmaid,Prince*2,King,Countess,Princess,Spy,Chancellor",
+		},
+	} {
+		state, err := ParseScenario(tt.scenario)
+		if !assert.NoError(t, err, tt.name) {
+			continue
+		}
+		state.Source = NewStackedSource(Baron, Handmaid)
+		state.PlayCard(tt.action, r)
+		assert.Equal(t, tt.expected, state.Scenario(), tt.name)
+	}
+}
+
+func TestScenarioRoundTrips(t *testing.T) {
+	for _, cards := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for i := 0; i < 10; i++ {
+			state, err := NewGameWithCards(cards, cards.MinPlayers+i%(cards.MaxPlayers-cards.MinPlayers+1), r)
+			assert.NoError(t, err)
+			for !state.GameEnded {
+				text := state.Scenario()
+				parsed, err := ParseScenario(text)
+				if !assert.NoError(t, err, text) {
+					break
+				}
+				assert.Equal(t, text, parsed.Scenario())
+				assert.Equal(t, state.Deck, parsed.Deck)
+				assert.Equal(t, state.CardInHand, parsed.CardInHand)
+				assert.Equal(t, state.ActivePlayerCard, parsed.ActivePlayerCard)
+				assert.Equal(t, state.LastPlay, parsed.LastPlay)
+				assert.Equal(t, state.KnownCards, parsed.KnownCards)
+				assert.Equal(t, state.EliminatedPlayers, parsed.EliminatedPlayers)
+				assert.Equal(t, state.LegalActions(), parsed.LegalActions())
+
+				acts := state.LegalActions()
+				state.PlayCard(acts[r.Intn(len(acts))], r)
+			}
+		}
+	}
+}
+
+func TestParseScenarioRejectsBadScenarios(t *testing.T) {
+	for name, text := range map[string]string{
+		"one player":     "p0: hand=Guard drawn=Guard",
+		"missing player": "p0: hand=Guard drawn=Guard; p2: hand=Priest",
+		"unknown card":   "p0: hand=Joker drawn=Guard; p1: hand=Priest",
+		"unknown key":    "p0: hand=Guard drawn=Guard colour=red; p1: hand=Priest",
+		"unknown cards":  "cards=tarot; p0: hand=Guard drawn=Guard; p1: hand=Priest",
+		"two hands":      "p0: hand=Guard,Priest drawn=Guard; p1: hand=Priest",
+		"wrong drawer":   "p0: hand=Guard; p1: hand=Priest drawn=Guard",
+		"no second card": "p0: hand=Guard; p1: hand=Priest",
+		"too many":       "p0: hand=Princess drawn=Princess; p1: hand=Priest",
+		"bad deck":       "p0: hand=Guard drawn=Guard; p1: hand=Priest; deck=Guard",
+		"bad knowledge":  "p0: hand=Guard drawn=Guard; p1: hand=Priest knows=p0:King",
+		"bad section":    "p0: hand=Guard drawn=Guard; p1: hand=Priest; winner",
+		"bad player":     "q0: hand=Guard drawn=Guard; p1: hand=Priest",
+	} {
+		_, err := ParseScenario(text)
+		assert.Error(t, err, name)
+	}
+}
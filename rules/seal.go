@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sealVersion prefixes every sealed token, so tokens in an older format are rejected instead of misread.
+// Version 1 sealed Gamestate.Token, and version 2 seals Gamestate.MarshalBinary.
+const sealVersion = "v2"
+
+var (
+	// ErrTokenVersion is returned when opening a sealed token that isn't in the current format (e.g. an old cookie).
+	ErrTokenVersion = errors.New("The token is not in the current format")
+
+	// ErrTokenInvalid is returned when a sealed token has been modified, or was sealed with a different key.
+	ErrTokenInvalid = errors.New("The token is not valid")
+
+	// ErrTokenExpired is returned when a sealed token is older than the Sealer allows.
+	ErrTokenExpired = errors.New("The token has expired")
+)
+
+// Sealer converts games to tokens that can be given to a player (e.g. in a cookie) without letting them read or change
+// the game. Tokens are encrypted with AES-CTR, then signed with HMAC-SHA256, and expire after a fixed time.
+type Sealer struct {
+	// TTL is how long a sealed token can be opened for.
+	TTL time.Duration
+
+	encryptKey, signKey []byte
+
+	// now returns the current time. It can be replaced in tests.
+	now func() time.Time
+}
+
+// NewSealer returns a Sealer with keys derived from the provided secret. The secret should be long and random.
+// The ttl must be positive, since tokens would otherwise expire before they could be opened.
+func NewSealer(secret []byte, ttl time.Duration) (*Sealer, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("The secret for sealing tokens is empty")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("The time to live for tokens is %v, but it must be positive", ttl)
+	}
+	return &Sealer{
+		TTL:        ttl,
+		encryptKey: deriveKey(secret, "encrypt"),
+		signKey:    deriveKey(secret, "sign"),
+		now:        time.Now,
+	}, nil
+}
+
+// deriveKey returns a 256-bit key for the purpose, so the same secret is never used for two things.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Seal returns a token for the game that can only be read by a Sealer with the same secret. Everything in the game is
+// kept, as with MarshalBinary.
+func (sealer *Sealer) Seal(game *Gamestate) (string, error) {
+	data, err := game.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	expiry := make([]byte, 8)
+	binary.BigEndian.PutUint64(expiry, uint64(sealer.now().Add(sealer.TTL).Unix()))
+	plaintext := append(expiry, data...)
+
+	block, err := aes.NewCipher(sealer.encryptKey)
+	if err != nil {
+		return "", err
+	}
+	sealed := make([]byte, aes.BlockSize+len(plaintext))
+	iv := sealed[:aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	cipher.NewCTR(block, iv).XORKeyStream(sealed[aes.BlockSize:], plaintext)
+	sealed = append(sealed, sealer.sign(sealed)...)
+
+	return sealVersion + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open returns the game in a sealed token, if the token is in the current format, unmodified, and not expired.
+func (sealer *Sealer) Open(token string) (Gamestate, error) {
+	var game Gamestate
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || parts[0] != sealVersion {
+		return game, ErrTokenVersion
+	}
+	sealed, err := base64.RawURLEncoding.Strict().DecodeString(parts[1])
+	if err != nil || len(sealed) < aes.BlockSize+8+sha256.Size {
+		return game, ErrTokenInvalid
+	}
+	signed, signature := sealed[:len(sealed)-sha256.Size], sealed[len(sealed)-sha256.Size:]
+	if !hmac.Equal(signature, sealer.sign(signed)) {
+		return game, ErrTokenInvalid
+	}
+
+	block, err := aes.NewCipher(sealer.encryptKey)
+	if err != nil {
+		return game, err
+	}
+	plaintext := make([]byte, len(signed)-aes.BlockSize)
+	cipher.NewCTR(block, signed[:aes.BlockSize]).XORKeyStream(plaintext, signed[aes.BlockSize:])
+
+	expiry := time.Unix(int64(binary.BigEndian.Uint64(plaintext[:8])), 0)
+	if sealer.now().After(expiry) {
+		return game, ErrTokenExpired
+	}
+	err = game.UnmarshalBinary(plaintext[8:])
+	return game, err
+}
+
+// sign returns the signature of the version and the data.
+func (sealer *Sealer) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, sealer.signKey)
+	mac.Write([]byte(sealVersion))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealRoundTrip(t *testing.T) {
+	sealer, err := NewSealer([]byte("a secret"), time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+	game, _ := NewGame(2, r)
+
+	token, err := sealer.Seal(&game)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, sealVersion+"."))
+	assert.NotContains(t, token, game.Token())
+
+	opened, err := sealer.Open(token)
+	assert.NoError(t, err)
+	assert.Equal(t, game.CardInHand, opened.CardInHand)
+	assert.Equal(t, game.Deck, opened.Deck)
+	assert.Equal(t, game.Token(), opened.Token())
+
+	// Sealing twice gives different tokens, so they can't be compared
+	again, _ := sealer.Seal(&game)
+	assert.NotEqual(t, token, again)
+}
+
+func TestSealRejectsBadTokens(t *testing.T) {
+	sealer, _ := NewSealer([]byte("a secret"), time.Hour)
+	game, _ := NewGame(2, r)
+	token, _ := sealer.Seal(&game)
+
+	_, err := sealer.Open(game.Token())
+	assert.Equal(t, ErrTokenVersion, err, "Plain tokens aren't accepted")
+	_, err = sealer.Open("v0" + token[2:])
+	assert.Equal(t, ErrTokenVersion, err)
+
+	// Changing any character invalidates the token
+	for i := len(sealVersion + "."); i < len(token); i++ {
+		replacement := "A"
+		if token[i] == 'A' {
+			replacement = "B"
+		}
+		_, err = sealer.Open(token[:i] + replacement + token[i+1:])
+		assert.Error(t, err)
+	}
+	_, err = sealer.Open(token[:len(token)-4])
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	other, _ := NewSealer([]byte("another secret"), time.Hour)
+	_, err = other.Open(token)
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	_, err = NewSealer(nil, time.Hour)
+	assert.Error(t, err)
+	_, err = NewSealer([]byte("a secret"), 0)
+	assert.Error(t, err, "Tokens would expire before they're opened")
+}
+
+func TestSealKeepsEveryCardSet(t *testing.T) {
+	sealer, _ := NewSealer([]byte("a secret"), time.Hour)
+	for _, cs := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for i := 0; i < 30; i++ {
+			numPlayers := cs.MinPlayers + i%(cs.MaxPlayers-cs.MinPlayers+1)
+			game, err := NewGameWithCards(cs, numPlayers, r)
+			assert.NoError(t, err)
+			// Play part of the game, so it may have pending draws, cards at the bottom of the deck, or have ended
+			for plays := 0; plays < i%8 && !game.GameEnded; plays++ {
+				acts := game.LegalActions()
+				game.PlayCard(acts[r.Intn(len(acts))], r)
+			}
+
+			token, err := sealer.Seal(&game)
+			assert.NoError(t, err)
+			opened, err := sealer.Open(token)
+			if !assert.NoError(t, err, cs.Name) {
+				continue
+			}
+			expected, _ := game.MarshalBinary()
+			actual, _ := opened.MarshalBinary()
+			assert.Equal(t, expected, actual, cs.Name)
+		}
+	}
+}
+
+func TestSealExpires(t *testing.T) {
+	sealer, _ := NewSealer([]byte("a secret"), time.Hour)
+	start := time.Now()
+	sealer.now = func() time.Time { return start }
+	game, _ := NewGame(2, r)
+	token, _ := sealer.Seal(&game)
+
+	sealer.now = func() time.Time { return start.Add(59 * time.Minute) }
+	_, err := sealer.Open(token)
+	assert.NoError(t, err)
+
+	sealer.now = func() time.Time { return start.Add(61 * time.Minute) }
+	_, err = sealer.Open(token)
+	assert.Equal(t, ErrTokenExpired, err)
+}
@@ -0,0 +1,496 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// serialVersion is the version of the JSON, binary and text formats. Games saved in any other version are rejected.
+const serialVersion = 2
+
+// binaryMagic starts every game saved in the binary format.
+const binaryMagic = "LLGS"
+
+// savedGame contains every field of a Gamestate that is saved. The Recording and Source aren't saved, just like they
+// aren't copied.
+type savedGame struct {
+	Version           int
+	CardSet           string `json:",omitempty"`
+	NumPlayers        int
+	Deck              Deck
+	Faceup            Stack
+	Discards          Stacks
+	LastPlay          Stack
+	KnownCards        Stacks
+	ActivePlayer      int
+	EliminatedPlayers []bool
+	CardInHand        Stack
+	ActivePlayerCard  Card
+	GameEnded         bool
+	Winner            int
+	Winners           []int
+	FinalState        FinalState
+	LossWasStupid     bool
+	PlayerNames       []string
+	Events            []savedEvent
+	DeckBottom        Stack
+	DeckBottomPlayers []int `json:",omitempty"`
+	PendingCard       Card
+	PendingDraws      Stack
+	BonusTokens       []int
+	ForcedTarget      int
+	JesterTokens      []int
+}
+
+// savedEvent is an Event, with the name it was registered as.
+type savedEvent struct {
+	Type  string
+	Event json.RawMessage
+}
+
+func (game Gamestate) save() (savedGame, error) {
+	saved := savedGame{
+		Version:           serialVersion,
+		NumPlayers:        game.NumPlayers,
+		Deck:              game.Deck,
+		Faceup:            game.Faceup,
+		Discards:          game.Discards,
+		LastPlay:          game.LastPlay,
+		KnownCards:        game.KnownCards,
+		ActivePlayer:      game.ActivePlayer,
+		EliminatedPlayers: game.EliminatedPlayers,
+		CardInHand:        game.CardInHand,
+		ActivePlayerCard:  game.ActivePlayerCard,
+		GameEnded:         game.GameEnded,
+		Winner:            game.Winner,
+		Winners:           game.Winners,
+		FinalState:        game.FinalState,
+		LossWasStupid:     game.LossWasStupid,
+		PlayerNames:       game.EventLog.PlayerNames,
+		DeckBottom:        game.DeckBottom,
+		DeckBottomPlayers: game.DeckBottomPlayers,
+		PendingCard:       game.PendingCard,
+		PendingDraws:      game.PendingDraws,
+		BonusTokens:       game.BonusTokens,
+		ForcedTarget:      game.ForcedTarget,
+		JesterTokens:      game.JesterTokens,
+	}
+	if game.CardSet != nil {
+		saved.CardSet = game.CardSet.Name
+	}
+	for _, event := range game.EventLog.Events {
+		name := eventName(event)
+		if _, ok := eventTypes[name]; !ok {
+			return saved, fmt.Errorf("The event type %s isn't registered, so it can't be saved", name)
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return saved, err
+		}
+		saved.Events = append(saved.Events, savedEvent{Type: name, Event: data})
+	}
+	return saved, nil
+}
+
+func (saved savedGame) load() (Gamestate, error) {
+	if saved.Version != serialVersion {
+		return Gamestate{}, fmt.Errorf("The game was saved in version %d, but only version %d can be loaded", saved.Version, serialVersion)
+	}
+	game := Gamestate{
+		NumPlayers:        saved.NumPlayers,
+		Deck:              saved.Deck,
+		Faceup:            saved.Faceup,
+		Discards:          saved.Discards,
+		LastPlay:          saved.LastPlay,
+		KnownCards:        saved.KnownCards,
+		ActivePlayer:      saved.ActivePlayer,
+		EliminatedPlayers: saved.EliminatedPlayers,
+		CardInHand:        saved.CardInHand,
+		ActivePlayerCard:  saved.ActivePlayerCard,
+		GameEnded:         saved.GameEnded,
+		Winner:            saved.Winner,
+		Winners:           saved.Winners,
+		FinalState:        saved.FinalState,
+		LossWasStupid:     saved.LossWasStupid,
+		EventLog:          EventLog{PlayerNames: saved.PlayerNames},
+		DeckBottom:        saved.DeckBottom,
+		DeckBottomPlayers: saved.DeckBottomPlayers,
+		PendingCard:       saved.PendingCard,
+		PendingDraws:      saved.PendingDraws,
+		BonusTokens:       saved.BonusTokens,
+		ForcedTarget:      saved.ForcedTarget,
+		JesterTokens:      saved.JesterTokens,
+	}
+	if saved.CardSet != "" {
+		cards, err := CardSetNamed(saved.CardSet)
+		if err != nil {
+			return Gamestate{}, err
+		}
+		game.CardSet = cards
+	}
+	for i, se := range saved.Events {
+		event, err := loadEvent(se)
+		if err != nil {
+			return Gamestate{}, fmt.Errorf("Event %d: %v", i, err)
+		}
+		game.EventLog.Events = append(game.EventLog.Events, event)
+	}
+
+	if len(game.EventLog.PlayerNames) != game.NumPlayers {
+		return Gamestate{}, fmt.Errorf("PlayerNames has %d entries for %d players", len(game.EventLog.PlayerNames), game.NumPlayers)
+	}
+	if game.PendingCard != None && game.cards().Definition(game.PendingCard) == nil {
+		return Gamestate{}, fmt.Errorf("The pending card %d isn't in the %s cards", game.PendingCard, game.cards().Name)
+	}
+	if err := game.Validate(); err != nil {
+		return Gamestate{}, err
+	}
+	return game, nil
+}
+
+// MarshalJSON encodes every field of the game, except for the Recording and Source.
+func (game Gamestate) MarshalJSON() ([]byte, error) {
+	saved, err := game.save()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON loads a game encoded by MarshalJSON. It returns an error if any field is unknown or inconsistent, or
+// if the cards in the game aren't exactly the cards in its CardSet.
+func (game *Gamestate) UnmarshalJSON(data []byte) error {
+	var saved savedGame
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&saved); err != nil {
+		return fmt.Errorf("The game is not valid JSON: %v", err)
+	}
+	loaded, err := saved.load()
+	if err != nil {
+		return err
+	}
+	*game = loaded
+	return nil
+}
+
+// MarshalBinary encodes every field of the game (like MarshalJSON) in a compact form.
+func (game Gamestate) MarshalBinary() ([]byte, error) {
+	saved, err := game.save()
+	if err != nil {
+		return nil, err
+	}
+	w := &binaryWriter{}
+	w.WriteString(binaryMagic)
+	w.int(saved.Version)
+	w.string(saved.CardSet)
+	w.int(saved.NumPlayers)
+	w.int(len(saved.Deck))
+	for _, count := range saved.Deck {
+		w.int(count)
+	}
+	w.stack(saved.Faceup)
+	w.stacks(saved.Discards)
+	w.stack(saved.LastPlay)
+	w.stacks(saved.KnownCards)
+	w.int(saved.ActivePlayer)
+	w.bools(saved.EliminatedPlayers)
+	w.stack(saved.CardInHand)
+	w.int(int(saved.ActivePlayerCard))
+	w.bool(saved.GameEnded)
+	w.int(saved.Winner)
+	w.ints(saved.Winners)
+	w.int(int(saved.FinalState.LastDiscard))
+	w.int(int(saved.FinalState.LastInHand))
+	w.int(int(saved.FinalState.OpponentInHand))
+	w.int(saved.FinalState.RemainingDeck)
+	w.bool(saved.FinalState.DiscardWon)
+	w.bool(saved.FinalState.SharedWin)
+	w.bool(saved.LossWasStupid)
+	w.int(len(saved.PlayerNames))
+	for _, name := range saved.PlayerNames {
+		w.string(name)
+	}
+	w.int(len(saved.Events))
+	for _, event := range saved.Events {
+		w.string(event.Type)
+		w.string(string(event.Event))
+	}
+	w.stack(saved.DeckBottom)
+	w.ints(saved.DeckBottomPlayers)
+	w.int(int(saved.PendingCard))
+	w.stack(saved.PendingDraws)
+	w.ints(saved.BonusTokens)
+	w.int(saved.ForcedTarget)
+	w.ints(saved.JesterTokens)
+	return w.Bytes(), nil
+}
+
+// UnmarshalBinary loads a game encoded by MarshalBinary, and checks it like UnmarshalJSON.
+func (game *Gamestate) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(binaryMagic)) {
+		return errors.New("The data is not a saved game")
+	}
+	r := &binaryReader{Reader: bytes.NewReader(data[len(binaryMagic):])}
+	saved := savedGame{}
+	saved.Version = r.int()
+	if r.err == nil && saved.Version != serialVersion {
+		return fmt.Errorf("The game was saved in version %d, but only version %d can be loaded", saved.Version, serialVersion)
+	}
+	saved.CardSet = r.string()
+	saved.NumPlayers = r.int()
+	if n := r.length(); n != len(saved.Deck) && r.err == nil {
+		r.err = fmt.Errorf("The deck has %d kinds of card, but %d are expected", n, len(saved.Deck))
+	}
+	for card := range saved.Deck {
+		saved.Deck[card] = r.int()
+	}
+	saved.Faceup = r.stack()
+	saved.Discards = r.stacks()
+	saved.LastPlay = r.stack()
+	saved.KnownCards = r.stacks()
+	saved.ActivePlayer = r.int()
+	saved.EliminatedPlayers = r.bools()
+	saved.CardInHand = r.stack()
+	saved.ActivePlayerCard = Card(r.int())
+	saved.GameEnded = r.bool()
+	saved.Winner = r.int()
+	saved.Winners = r.ints()
+	saved.FinalState.LastDiscard = Card(r.int())
+	saved.FinalState.LastInHand = Card(r.int())
+	saved.FinalState.OpponentInHand = Card(r.int())
+	saved.FinalState.RemainingDeck = r.int()
+	saved.FinalState.DiscardWon = r.bool()
+	saved.FinalState.SharedWin = r.bool()
+	saved.LossWasStupid = r.bool()
+	if n := r.length(); n >= 0 {
+		saved.PlayerNames = make([]string, n)
+		for i := range saved.PlayerNames {
+			saved.PlayerNames[i] = r.string()
+		}
+	}
+	if n := r.length(); n >= 0 {
+		saved.Events = make([]savedEvent, n)
+		for i := range saved.Events {
+			saved.Events[i].Type = r.string()
+			saved.Events[i].Event = json.RawMessage(r.string())
+		}
+	}
+	saved.DeckBottom = r.stack()
+	saved.DeckBottomPlayers = r.ints()
+	saved.PendingCard = Card(r.int())
+	saved.PendingDraws = r.stack()
+	saved.BonusTokens = r.ints()
+	saved.ForcedTarget = r.int()
+	saved.JesterTokens = r.ints()
+	if r.err == nil && r.Len() > 0 {
+		r.err = fmt.Errorf("The data has %d unexpected bytes at the end", r.Len())
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	loaded, err := saved.load()
+	if err != nil {
+		return err
+	}
+	*game = loaded
+	return nil
+}
+
+// MarshalText encodes every field of the game (like MarshalBinary) as URL-safe text.
+// Unlike Token, nothing is lost, and any CardSet can be used.
+func (game Gamestate) MarshalText() ([]byte, error) {
+	data, err := game.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	text := make([]byte, base64.RawURLEncoding.EncodedLen(len(data)))
+	base64.RawURLEncoding.Encode(text, data)
+	return text, nil
+}
+
+// UnmarshalText loads a game encoded by MarshalText, and checks it like UnmarshalJSON.
+func (game *Gamestate) UnmarshalText(text []byte) error {
+	data := make([]byte, base64.RawURLEncoding.DecodedLen(len(text)))
+	n, err := base64.RawURLEncoding.Strict().Decode(data, text)
+	if err != nil {
+		return fmt.Errorf("The text is not a saved game: %v", err)
+	}
+	return game.UnmarshalBinary(data[:n])
+}
+
+// binaryWriter writes the values used by MarshalBinary. Integers are varints, and slices are prefixed by their
+// length, or -1 if they're nil.
+type binaryWriter struct {
+	bytes.Buffer
+}
+
+func (w *binaryWriter) int(val int) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutVarint(buf[:], int64(val))])
+}
+
+func (w *binaryWriter) bool(val bool) {
+	if val {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+}
+
+func (w *binaryWriter) string(val string) {
+	w.int(len(val))
+	w.WriteString(val)
+}
+
+func (w *binaryWriter) stack(stack Stack) {
+	if stack == nil {
+		w.int(-1)
+		return
+	}
+	w.int(len(stack))
+	for _, card := range stack {
+		w.int(int(card))
+	}
+}
+
+func (w *binaryWriter) stacks(stacks Stacks) {
+	if stacks == nil {
+		w.int(-1)
+		return
+	}
+	w.int(len(stacks))
+	for _, stack := range stacks {
+		w.stack(stack)
+	}
+}
+
+func (w *binaryWriter) ints(vals []int) {
+	if vals == nil {
+		w.int(-1)
+		return
+	}
+	w.int(len(vals))
+	for _, val := range vals {
+		w.int(val)
+	}
+}
+
+func (w *binaryWriter) bools(vals []bool) {
+	if vals == nil {
+		w.int(-1)
+		return
+	}
+	w.int(len(vals))
+	for _, val := range vals {
+		w.bool(val)
+	}
+}
+
+// binaryReader reads the values written by a binaryWriter. Once there's an error, it's kept and every value is zero.
+type binaryReader struct {
+	*bytes.Reader
+	err error
+}
+
+func (r *binaryReader) int() int {
+	if r.err != nil {
+		return 0
+	}
+	val, err := binary.ReadVarint(r.Reader)
+	if err != nil {
+		r.err = errors.New("The saved game ends unexpectedly")
+		return 0
+	}
+	return int(val)
+}
+
+// length reads the length of a slice, which is -1 for a nil slice. A length longer than the remaining data is an error.
+func (r *binaryReader) length() int {
+	n := r.int()
+	if r.err == nil && (n < -1 || n > r.Len()) {
+		r.err = fmt.Errorf("The saved game has an invalid length (%d)", n)
+	}
+	if r.err != nil {
+		return -1
+	}
+	return n
+}
+
+func (r *binaryReader) bool() bool {
+	if r.err != nil {
+		return false
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		r.err = errors.New("The saved game ends unexpectedly")
+		return false
+	}
+	if b > 1 {
+		r.err = fmt.Errorf("The saved game has an invalid bool (%d)", b)
+	}
+	return b == 1
+}
+
+func (r *binaryReader) string() string {
+	n := r.length()
+	if n < 0 {
+		return ""
+	}
+	buf := make([]byte, n)
+	r.Read(buf)
+	return string(buf)
+}
+
+func (r *binaryReader) stack() Stack {
+	n := r.length()
+	if n < 0 {
+		return nil
+	}
+	stack := make(Stack, n)
+	for i := range stack {
+		stack[i] = Card(r.int())
+	}
+	return stack
+}
+
+func (r *binaryReader) stacks() Stacks {
+	n := r.length()
+	if n < 0 {
+		return nil
+	}
+	stacks := make(Stacks, n)
+	for i := range stacks {
+		stacks[i] = r.stack()
+	}
+	return stacks
+}
+
+func (r *binaryReader) ints() []int {
+	n := r.length()
+	if n < 0 {
+		return nil
+	}
+	vals := make([]int, n)
+	for i := range vals {
+		vals[i] = r.int()
+	}
+	return vals
+}
+
+func (r *binaryReader) bools() []bool {
+	n := r.length()
+	if n < 0 {
+		return nil
+	}
+	vals := make([]bool, n)
+	for i := range vals {
+		vals[i] = r.bool()
+	}
+	return vals
+}
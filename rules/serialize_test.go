@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerializationRoundTrips(t *testing.T) {
+	for _, cards := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for i := 0; i < 10; i++ {
+			state, err := NewGameWithCards(cards, cards.MinPlayers+i%(cards.MaxPlayers-cards.MinPlayers+1), r)
+			assert.NoError(t, err)
+			for {
+				data, err := json.Marshal(state)
+				assert.NoError(t, err)
+				loaded := Gamestate{}
+				assert.NoError(t, json.Unmarshal(data, &loaded))
+				assert.Equal(t, state, loaded)
+
+				data, err = state.MarshalBinary()
+				assert.NoError(t, err)
+				loaded = Gamestate{}
+				assert.NoError(t, loaded.UnmarshalBinary(data))
+				assert.Equal(t, state, loaded)
+
+				text, err := state.MarshalText()
+				assert.NoError(t, err)
+				loaded = Gamestate{}
+				assert.NoError(t, loaded.UnmarshalText(text))
+				assert.Equal(t, state, loaded)
+
+				if state.GameEnded {
+					break
+				}
+				acts := state.LegalActions()
+				state.PlayCard(acts[r.Intn(len(acts))], r)
+			}
+		}
+	}
+}
+
+func TestUnmarshalJSONRejectsBadGames(t *testing.T) {
+	state, _ := NewGame(2, r)
+	modify := func(change func(saved map[string]interface{})) []byte {
+		data, _ := json.Marshal(state)
+		saved := map[string]interface{}{}
+		json.Unmarshal(data, &saved)
+		change(saved)
+		data, _ = json.Marshal(saved)
+		return data
+	}
+	broken := func(change func(game *Gamestate)) []byte {
+		game := state.Copy()
+		change(&game)
+		data, _ := json.Marshal(game)
+		return data
+	}
+
+	for name, data := range map[string][]byte{
+		"not JSON":      []byte("{"),
+		"unknown field": modify(func(saved map[string]interface{}) { saved["Cheat"] = true }),
+		"old version":   modify(func(saved map[string]interface{}) { saved["Version"] = 0 }),
+		"unknown cards": modify(func(saved map[string]interface{}) { saved["CardSet"] = "missing" }),
+		"extra card":    modify(func(saved map[string]interface{}) { saved["CardInHand"] = []int{int(Princess), int(Princess)} }),
+		"missing card":  modify(func(saved map[string]interface{}) { saved["Faceup"] = []int{} }),
+		"short":         modify(func(saved map[string]interface{}) { saved["LastPlay"] = []int{0} }),
+		"active player": modify(func(saved map[string]interface{}) { saved["ActivePlayer"] = 2 }),
+		"negative deck count": broken(func(game *Gamestate) {
+			for ; game.Deck[Guard] > -1; game.Deck[Guard]-- {
+				game.Faceup = append(game.Faceup, Guard)
+			}
+		}),
+		"eliminated with card": broken(func(game *Gamestate) { game.EliminatedPlayers[1] = true }),
+		"wrong knowledge": broken(func(game *Gamestate) {
+			game.KnownCards[1][0] = Guard
+			if game.CardInHand[1] == Guard {
+				game.KnownCards[1][0] = Priest
+			}
+		}),
+		"no winners": broken(func(game *Gamestate) { game.GameEnded = true }),
+		"unknown event": modify(func(saved map[string]interface{}) {
+			saved["Events"] = []map[string]interface{}{{"Type": "Cheated", "Event": map[string]interface{}{}}}
+		}),
+		"bad event": modify(func(saved map[string]interface{}) {
+			saved["Events"] = []map[string]interface{}{{"Type": "KingSwap", "Event": map[string]interface{}{"Card": 1}}}
+		}),
+	} {
+		loaded := Gamestate{}
+		assert.Error(t, json.Unmarshal(data, &loaded), name)
+	}
+}
+
+type unregisteredEvent struct{ GameStarted }
+
+func TestMarshalRejectsUnregisteredEvents(t *testing.T) {
+	state, _ := NewGame(2, r)
+	state.RecordEvent(unregisteredEvent{})
+	_, err := json.Marshal(state)
+	assert.Error(t, err)
+	_, err = state.MarshalBinary()
+	assert.Error(t, err)
+}
+
+func TestUnmarshalBinaryRejectsBadData(t *testing.T) {
+	state, _ := NewGame(4, r)
+	data, _ := state.MarshalBinary()
+
+	loaded := Gamestate{}
+	assert.Error(t, loaded.UnmarshalBinary(data[:len(data)-1]), "Truncated")
+	assert.Error(t, loaded.UnmarshalBinary(append(data, 0)), "Trailing bytes")
+	assert.Error(t, loaded.UnmarshalBinary([]byte("LLGS")))
+	assert.Error(t, loaded.UnmarshalBinary([]byte("{}")))
+	assert.Error(t, loaded.UnmarshalText([]byte("not a game")))
+
+	// The binary format is more compact than JSON
+	text, _ := json.Marshal(state)
+	assert.True(t, len(data) < len(text))
+}
+
+func TestFromTokenRejectsBadTokens(t *testing.T) {
+	for _, tok := range []string{
+		"2.2302.[443].[[]-[]].[00].[[00]-[00]].x.00.[78].1",
+		"2.2302.[443].[[]-[]].[00].[[00]-[00]].0.0x.[78].1",
+		"2.2302.[44x].[[]-[]].[00].[[00]-[00]].0.00.[78].1",
+		"2.2302.[443].[[]-[]].[00].[[00]-[00]].0.00.78.1",
+		"2.2302.[443].[[]-[]].[00].[[00]-[00]].0.00.[88].1",
+		"2.2302.[443].[[]-[]].[00].[[00]-[00]].5.00.[78].1",
+	} {
+		game := &Gamestate{}
+		assert.Error(t, game.FromToken(tok), tok)
+	}
+}
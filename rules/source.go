@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// CardSource decides which card is drawn from a deck, so games can be dealt from a stacked or physical deck instead
+// of at random.
+type CardSource interface {
+	// Draw removes a card from the deck and returns it. It returns None if the deck is empty.
+	Draw(deck *Deck) Card
+}
+
+// RandomSource draws cards at random, like Deck.Draw.
+type RandomSource struct {
+	*rand.Rand
+}
+
+func (rs RandomSource) Draw(deck *Deck) Card {
+	return deck.Draw(rs.Rand)
+}
+
+// StackedSource draws the provided cards in order, and then draws from Then.
+// If the next card isn't in the deck, or there are no cards left and Then is nil, None is drawn and Err reports the
+// problem.
+type StackedSource struct {
+	// Cards are drawn in order.
+	Cards Stack
+
+	// Then is used once Cards is empty. It can be nil.
+	Then CardSource
+
+	err error
+}
+
+// NewStackedSource returns a StackedSource for the cards, which fails once they have all been drawn.
+func NewStackedSource(cards ...Card) *StackedSource {
+	return &StackedSource{Cards: Stack(cards).Copy()}
+}
+
+func (ss *StackedSource) Draw(deck *Deck) Card {
+	if len(ss.Cards) == 0 {
+		if ss.Then != nil {
+			return ss.Then.Draw(deck)
+		}
+		if deck.Size() > 0 {
+			ss.fail(errors.New("more cards were drawn than were stacked"))
+		}
+		return None
+	}
+	card := ss.Cards[0]
+	ss.Cards = ss.Cards[1:]
+	if card == None && deck.Size() == 0 {
+		return None
+	}
+	if card <= None || card >= numberOfCards || deck[card] == 0 {
+		ss.fail(errors.New("a stacked " + card.String() + " isn't left in the deck"))
+		return None
+	}
+	deck[card]--
+	return card
+}
+
+// Err returns the first problem drawing the stacked cards, or nil.
+func (ss *StackedSource) Err() error {
+	return ss.err
+}
+
+func (ss *StackedSource) fail(err error) {
+	if ss.err == nil {
+		ss.err = err
+	}
+}
+
+// ShuffledSource draws from an ordered deck, like a physical deck of cards. The first card in the order is the burn
+// card, which is set aside face-down and only drawn once every other card is gone.
+// Cards in the order that are no longer in the deck being drawn from are skipped.
+type ShuffledSource struct {
+	burn     Card
+	burnLeft bool
+	order    Stack
+}
+
+// NewShuffledSource returns a ShuffledSource for the cards in order, starting with the burn card.
+// Sources created from the same order draw the same cards, which allows a deal to be replayed with the seats swapped.
+func NewShuffledSource(order Stack) *ShuffledSource {
+	if len(order) == 0 {
+		return &ShuffledSource{}
+	}
+	return &ShuffledSource{
+		burn:     order[0],
+		burnLeft: true,
+		order:    order[1:].Copy(),
+	}
+}
+
+// Shuffle returns every card in the deck in a random order.
+func Shuffle(deck Deck, r *rand.Rand) Stack {
+	order := make(Stack, 0, deck.Size())
+	for deck.Size() > 0 {
+		order = append(order, deck.Draw(r))
+	}
+	return order
+}
+
+func (ss *ShuffledSource) Draw(deck *Deck) Card {
+	for len(ss.order) > 0 {
+		card := ss.order[0]
+		ss.order = ss.order[1:]
+		reserved := 0
+		if ss.burnLeft && card == ss.burn {
+			reserved = 1
+		}
+		if card > None && card < numberOfCards && deck[card] > reserved {
+			deck[card]--
+			return card
+		}
+	}
+	if ss.burnLeft && ss.burn > None && ss.burn < numberOfCards && deck[ss.burn] > 0 {
+		ss.burnLeft = false
+		deck[ss.burn]--
+		return ss.burn
+	}
+	return None
+}
@@ -0,0 +1,84 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackedSource(t *testing.T) {
+	deck := Deck{Guard: 2, Priest: 1}
+	src := NewStackedSource(Priest, Guard)
+
+	assert.Equal(t, Priest, src.Draw(&deck))
+	assert.Equal(t, Guard, src.Draw(&deck))
+	assert.NoError(t, src.Err())
+	assert.Equal(t, Deck{Guard: 1}, deck)
+
+	assert.Equal(t, None, src.Draw(&deck))
+	assert.Error(t, src.Err(), "No more cards were stacked")
+}
+
+func TestStackedSourceMissingCard(t *testing.T) {
+	deck := Deck{Guard: 2}
+	src := NewStackedSource(Princess)
+	assert.Equal(t, None, src.Draw(&deck))
+	assert.Error(t, src.Err())
+	assert.Equal(t, Deck{Guard: 2}, deck)
+}
+
+func TestStackedSourceThen(t *testing.T) {
+	deck := Deck{Guard: 2, Priest: 1}
+	src := &StackedSource{Cards: Stack{Priest}, Then: NewStackedSource(Guard)}
+	assert.Equal(t, Priest, src.Draw(&deck))
+	assert.Equal(t, Guard, src.Draw(&deck))
+	assert.NoError(t, src.Err())
+}
+
+func TestShuffledSourceBurnsFirstCard(t *testing.T) {
+	deck := Deck{Guard: 2, Priest: 1, Princess: 1}
+	src := NewShuffledSource(Stack{Guard, Princess, Guard, Priest})
+
+	assert.Equal(t, Princess, src.Draw(&deck))
+	assert.Equal(t, Guard, src.Draw(&deck))
+	assert.Equal(t, Priest, src.Draw(&deck))
+	assert.Equal(t, Guard, src.Draw(&deck), "The burn card is drawn last")
+	assert.Equal(t, None, src.Draw(&deck))
+}
+
+func TestShuffleContainsDeck(t *testing.T) {
+	order := Shuffle(DefaultDeck(), r)
+	assert.Len(t, order, 16)
+	assert.Equal(t, DefaultDeck(), order.AsDeck())
+}
+
+func TestNewGameFromStackedDeck(t *testing.T) {
+	src := NewStackedSource(Guard, Guard, Guard, Priest, Baron, Princess, Countess)
+	state, err := NewGameFromSource(ClassicCards, 2, src)
+	assert.NoError(t, err)
+	assert.Equal(t, Stack{Guard, Guard, Guard}, state.Faceup)
+	assert.Equal(t, Stack{Priest, Baron}, state.CardInHand)
+	assert.Equal(t, Princess, state.ActivePlayerCard)
+
+	state.PlayCard(Action{PlayRecent: false, TargetPlayerOffset: 1}, nil)
+	assert.Equal(t, Countess, state.ActivePlayerCard)
+	assert.Equal(t, 1, state.ActivePlayer)
+	assert.NoError(t, src.Err())
+}
+
+func TestSameShuffleInBothSeats(t *testing.T) {
+	order := Shuffle(DefaultDeck(), r)
+	first, err := NewGameFromSource(ClassicCards, 2, NewShuffledSource(order))
+	assert.NoError(t, err)
+	second, err := NewGameFromSource(ClassicCards, 2, NewShuffledSource(order))
+	assert.NoError(t, err)
+
+	assert.Equal(t, first.CardInHand, second.CardInHand)
+	assert.Equal(t, first.Faceup, second.Faceup)
+
+	var last Card
+	for first.Deck.Size() > 0 {
+		last = first.Source.Draw(&first.Deck)
+	}
+	assert.Equal(t, order[0], last, "The burn card is drawn last")
+}
@@ -7,12 +7,19 @@ import (
 	"strings"
 )
 
-const tokenFormat = "%d.%d.%s.%s.%s.%s.%d.%s.%s.%d"
+const tokenFormat = "%d.%s.%s.%s.%s.%s.%d.%s.%s.%d"
 
+// Token encodes the game as a string. If the game doesn't use ClassicCards, the name of its CardSet is appended, and
+// the deck is written as a stack, since Deck.AsInt only counts the classic cards.
 func (game *Gamestate) Token() string {
-	return fmt.Sprintf(tokenFormat,
+	cards := game.cards()
+	deck := strconv.Itoa(game.Deck.AsInt())
+	if cards != ClassicCards {
+		deck = game.Deck.AsStack().Token()
+	}
+	tok := fmt.Sprintf(tokenFormat,
 		game.NumPlayers,
-		game.Deck.AsInt(),
+		deck,
 		game.Faceup.Token(),
 		game.Discards.Token(),
 		game.LastPlay.Token(),
@@ -21,34 +28,89 @@ func (game *Gamestate) Token() string {
 		stringForElim(game.EliminatedPlayers),
 		game.CardInHand.Token(),
 		int(game.ActivePlayerCard))
+	if cards != ClassicCards {
+		tok += "." + cards.Name
+	}
+	return tok
 }
 
+// FromToken loads a game encoded by Token. It returns an error if any value can't be read, or if the cards in the game
+// aren't exactly the cards in its CardSet. Tokens don't include whether the game ended, so they're only for games in
+// progress. MarshalText and MarshalJSON keep everything.
 func (game *Gamestate) FromToken(tok string) error {
 	strs := strings.Split(tok, ".")
-	if len(strs) != 10 {
+	if len(strs) != 10 && len(strs) != 11 {
 		return errors.New("Token '" + tok + "' does not have the expected 10 values")
 	}
 
-	game.NumPlayers = unsafeAtoi(strs[0])
-	game.Deck.FromInt(unsafeAtoi(strs[1]))
-	game.Faceup.FromToken(strs[2])
-	game.Discards.FromToken(strs[3])
-	game.LastPlay.FromToken(strs[4])
-	game.KnownCards.FromToken(strs[5])
-	game.ActivePlayer = unsafeAtoi(strs[6])
+	game.CardSet = nil
+	if len(strs) == 11 {
+		cards, err := CardSetNamed(strs[10])
+		if err != nil {
+			return err
+		}
+		game.CardSet = cards
+	}
+
+	ints := map[int]int{}
+	for _, i := range []int{0, 6, 9} {
+		val, err := strconv.Atoi(strs[i])
+		if err != nil {
+			return fmt.Errorf("Token '%s' has '%s' instead of a number", tok, strs[i])
+		}
+		ints[i] = val
+	}
+	if strings.Trim(strs[7], "01") != "" {
+		return fmt.Errorf("Token '%s' has '%s' instead of the eliminated players", tok, strs[7])
+	}
+
+	game.NumPlayers = ints[0]
+	if game.cards() == ClassicCards {
+		deck, err := strconv.Atoi(strs[1])
+		if err != nil {
+			return fmt.Errorf("Token '%s' has '%s' instead of a number", tok, strs[1])
+		}
+		game.Deck.FromInt(deck)
+	} else {
+		deck := Stack{}
+		if err := deck.FromToken(strs[1]); err != nil {
+			return err
+		}
+		game.Deck = deck.AsDeck()
+	}
+	if err := game.Faceup.FromToken(strs[2]); err != nil {
+		return err
+	}
+	if err := game.Discards.FromToken(strs[3]); err != nil {
+		return err
+	}
+	if err := game.LastPlay.FromToken(strs[4]); err != nil {
+		return err
+	}
+	if err := game.KnownCards.FromToken(strs[5]); err != nil {
+		return err
+	}
+	game.ActivePlayer = ints[6]
 	game.EliminatedPlayers = elimFromString(strs[7])
-	game.CardInHand.FromToken(strs[8])
-	game.ActivePlayerCard = Card(unsafeAtoi(strs[9]))
+	if err := game.CardInHand.FromToken(strs[8]); err != nil {
+		return err
+	}
+	game.ActivePlayerCard = Card(ints[9])
+	game.ForcedTarget = -1
 
 	game.EventLog = newEventLog(game.NumPlayers)
-	game.EventLog.log(fmt.Sprintf("Game was created from token %s", tok))
+	game.EventLog.Events = []Event{GameStarted{NumPlayers: game.NumPlayers, Token: tok}}
 
-	var err error
 	if !game.isValid() {
 		return errors.New("Token '" + tok + "' is not a valid game")
 	}
-
-	return err
+	if err := game.checkShape(); err != nil {
+		return fmt.Errorf("Token '%s' is not a valid game: %v", tok, err)
+	}
+	if err := game.checkCards(); err != nil {
+		return fmt.Errorf("Token '%s' is not a valid game: %v", tok, err)
+	}
+	return nil
 }
 
 func stringForElim(elim []bool) string {
@@ -70,14 +132,6 @@ func elimFromString(str string) []bool {
 	return ep
 }
 
-func unsafeAtoi(str string) int {
-	val, err := strconv.Atoi(str)
-	if err != nil {
-		return 0
-	}
-	return val
-}
-
 func (game *Gamestate) isValid() bool {
 	if len(game.Discards) != game.NumPlayers {
 		return false
@@ -0,0 +1,201 @@
+package rules
+
+import "fmt"
+
+// ValidateEveryPlay makes PlayCard call Validate after every action, and panic if the game is no longer valid.
+// It's intended for debugging (e.g. during long training runs), since it slows every game down.
+var ValidateEveryPlay = false
+
+// Validate returns an error describing the first problem found with the game, or nil if it's consistent. It checks
+// that every card in the CardSet is in exactly one place, that every field has an entry for each player, and that
+// the active player, eliminated players, hands, last plays and known cards agree with each other.
+func (state *Gamestate) Validate() error {
+	if err := state.checkShape(); err != nil {
+		return err
+	}
+	if err := state.checkCards(); err != nil {
+		return err
+	}
+	return state.checkPlayers()
+}
+
+// mustBeValid panics if the game isn't valid after the player's action.
+func (state *Gamestate) mustBeValid(player int, action Action) {
+	if err := state.Validate(); err != nil {
+		panic(fmt.Sprintf("The game is invalid after player %d played %+v: %v", player, action, err))
+	}
+}
+
+// checkPlayers returns an error if the players' cards and knowledge don't agree with who is still in the game.
+func (state *Gamestate) checkPlayers() error {
+	remaining := 0
+	for pid, eliminated := range state.EliminatedPlayers {
+		if eliminated {
+			if state.CardInHand[pid] != None {
+				return fmt.Errorf("Player %d was eliminated, but still has a %s", pid, state.CardInHand[pid])
+			}
+			continue
+		}
+		remaining++
+		if state.CardInHand[pid] == None {
+			return fmt.Errorf("Player %d is still in the game, but has no card", pid)
+		}
+	}
+
+	if !state.GameEnded {
+		if remaining < 2 {
+			return fmt.Errorf("The game hasn't ended, but only %d player(s) remain", remaining)
+		}
+		if state.EliminatedPlayers[state.ActivePlayer] {
+			return fmt.Errorf("The active player %d was eliminated, but the game hasn't ended", state.ActivePlayer)
+		}
+		if state.PendingCard == None && state.ActivePlayerCard == None {
+			return fmt.Errorf("The active player %d has no second card", state.ActivePlayer)
+		}
+	} else {
+		if len(state.Winners) == 0 || state.Winner != state.Winners[0] {
+			return fmt.Errorf("The game ended, but the winner %d isn't the first of the winners %v", state.Winner, state.Winners)
+		}
+		for _, winner := range state.Winners {
+			if winner < 0 || winner >= len(state.EliminatedPlayers) || state.EliminatedPlayers[winner] {
+				return fmt.Errorf("The game ended, but the winner %d isn't a remaining player", winner)
+			}
+		}
+	}
+	if state.PendingCard != None && len(state.PendingDraws) == 0 {
+		return fmt.Errorf("The %s is pending, but no cards were drawn for it", state.PendingCard)
+	}
+
+	for pid, card := range state.LastPlay {
+		if card != None && !containsCard(state.Discards[pid], card) {
+			return fmt.Errorf("Player %d last played a %s, which isn't in their discards", pid, card)
+		}
+	}
+
+	for about, knowers := range state.KnownCards {
+		for knower, known := range knowers {
+			if known == None {
+				continue
+			}
+			if knower == about {
+				return fmt.Errorf("Player %d is recorded as knowing their own card", about)
+			}
+			if known != state.CardInHand[about] {
+				return fmt.Errorf("Player %d knows that player %d has a %s, but they have a %s", knower, about, known, state.CardInHand[about])
+			}
+		}
+	}
+	return nil
+}
+
+// containsCard returns true if the stack contains the card.
+func containsCard(stack Stack, card Card) bool {
+	for _, val := range stack {
+		if val == card {
+			return true
+		}
+	}
+	return false
+}
+
+// checkShape returns an error if a field that has an entry for each player doesn't, or if a field refers to a player
+// who isn't in the game.
+func (state *Gamestate) checkShape() error {
+	n := state.NumPlayers
+	if n < 1 {
+		return fmt.Errorf("A game can't have %d players", n)
+	}
+	lengths := map[string]int{
+		"Discards":          len(state.Discards),
+		"LastPlay":          len(state.LastPlay),
+		"KnownCards":        len(state.KnownCards),
+		"EliminatedPlayers": len(state.EliminatedPlayers),
+		"CardInHand":        len(state.CardInHand),
+	}
+	for pid, known := range state.KnownCards {
+		lengths[fmt.Sprintf("KnownCards[%d]", pid)] = len(known)
+	}
+	if state.BonusTokens != nil {
+		lengths["BonusTokens"] = len(state.BonusTokens)
+	}
+	if state.JesterTokens != nil {
+		lengths["JesterTokens"] = len(state.JesterTokens)
+	}
+	for name, length := range lengths {
+		if length != n {
+			return fmt.Errorf("%s has %d entries for %d players", name, length, n)
+		}
+	}
+
+	if state.ActivePlayer < 0 || state.ActivePlayer >= n {
+		return fmt.Errorf("The active player %d isn't in the game", state.ActivePlayer)
+	}
+	if state.Winner < -1 || state.Winner >= n {
+		return fmt.Errorf("The winner %d isn't in the game", state.Winner)
+	}
+	if state.ForcedTarget < -1 || state.ForcedTarget >= n {
+		return fmt.Errorf("The forced target %d isn't in the game", state.ForcedTarget)
+	}
+	if state.DeckBottomPlayers != nil && len(state.DeckBottomPlayers) != len(state.DeckBottom) {
+		return fmt.Errorf("DeckBottomPlayers has %d entries for %d cards", len(state.DeckBottomPlayers), len(state.DeckBottom))
+	}
+	for i, placer := range state.DeckBottomPlayers {
+		if placer < -1 || placer >= n {
+			return fmt.Errorf("Card %d on the bottom of the deck was put there by player %d, who isn't in the game", i, placer)
+		}
+	}
+	for pid, giver := range state.JesterTokens {
+		if giver < -1 || giver >= n {
+			return fmt.Errorf("Player %d's Jester token is from player %d, who isn't in the game", pid, giver)
+		}
+	}
+	return nil
+}
+
+// checkCards returns an error unless every card in the CardSet is in exactly one place: the deck (which can't have a
+// negative count that hides an extra copy elsewhere), the bottom of the deck, face-up, a discard pile, a hand, or the cards drawn for a pending card. Once the active player has played
+// their second card (i.e. the game ended, or a card is pending), ActivePlayerCard is also in their discards, so it
+// isn't counted.
+func (state *Gamestate) checkCards() error {
+	cards := state.cards()
+	for card, count := range state.Deck {
+		if count < 0 {
+			return fmt.Errorf("The deck has %d copies of card %d (%s)", count, card, Card(card))
+		}
+		if count > 0 && cards.Definition(Card(card)) == nil {
+			return fmt.Errorf("The deck contains card %d (%s), which isn't in the %s cards", card, Card(card), cards.Name)
+		}
+	}
+	found := state.Deck.Copy()
+	stacks := map[string]Stack{
+		"Faceup":       state.Faceup,
+		"DeckBottom":   state.DeckBottom,
+		"PendingDraws": state.PendingDraws,
+		"CardInHand":   state.CardInHand,
+	}
+	if !state.GameEnded && state.PendingCard == None {
+		stacks["ActivePlayerCard"] = Stack{state.ActivePlayerCard}
+	}
+	for pid, discards := range state.Discards {
+		stacks[fmt.Sprintf("Player %d's discards", pid)] = discards
+	}
+	for name, stack := range stacks {
+		for _, card := range stack {
+			if card == None {
+				continue
+			}
+			if cards.Definition(card) == nil {
+				return fmt.Errorf("%s contains a %s, which isn't in the %s cards", name, card, cards.Name)
+			}
+			found[card]++
+		}
+	}
+
+	expected := cards.Deck()
+	for card := range expected {
+		if found[card] != expected[card] {
+			return fmt.Errorf("The game has %d copies of card %d (%s), but the %s cards have %d", found[card], card, Card(card), cards.Name, expected[card])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGamesStayValid(t *testing.T) {
+	ValidateEveryPlay = true
+	defer func() { ValidateEveryPlay = false }()
+
+	for _, cards := range []*CardSet{ClassicCards, Edition2019Cards, PremiumCards} {
+		for n := cards.MinPlayers; n <= cards.MaxPlayers; n++ {
+			for i := 0; i < 50; i++ {
+				state, _ := NewGameWithCards(cards, n, r)
+				assert.NoError(t, state.Validate())
+				for !state.GameEnded {
+					if r.Intn(5) > 0 {
+						acts := state.LegalActions()
+						state.PlayCard(acts[r.Intn(len(acts))], r)
+						continue
+					}
+					// Illegal actions must leave the game valid too
+					state.PlayCard(Action{
+						PlayRecent:         r.Intn(2) == 0,
+						TargetPlayerOffset: r.Intn(n + 1),
+						SecondTargetOffset: r.Intn(n),
+						SelectedCard:       Card(r.Intn(int(numberOfCards))),
+						KeepCard:           Card(r.Intn(int(numberOfCards))),
+					}, r)
+				}
+				assert.NoError(t, state.Validate())
+			}
+		}
+	}
+}
+
+func TestValidateFindsProblems(t *testing.T) {
+	for name, breakGame := range map[string]func(state *Gamestate){
+		"extra card":   func(state *Gamestate) { state.Discards[1] = Stack{Princess} },
+		"missing card": func(state *Gamestate) { state.Deck[Guard]-- },
+		"negative deck count": func(state *Gamestate) {
+			// The total is right, but the deck's -1 hides an extra Guard
+			for ; state.Deck[Guard] > -1; state.Deck[Guard]-- {
+				state.Faceup = append(state.Faceup, Guard)
+			}
+		},
+		"card from other set": func(state *Gamestate) { state.CardInHand[3] = Spy },
+		"eliminated with card": func(state *Gamestate) {
+			state.EliminatedPlayers[2] = true
+		},
+		"remaining without card": func(state *Gamestate) {
+			state.Discards[1] = Stack{state.CardInHand[1]}
+			state.CardInHand[1] = None
+		},
+		"active player eliminated": func(state *Gamestate) {
+			state.Discards[0] = Stack{state.CardInHand[0]}
+			state.CardInHand[0] = None
+			state.EliminatedPlayers[0] = true
+		},
+		"last play not discarded": func(state *Gamestate) { state.LastPlay[1] = Guard },
+		"wrong knowledge": func(state *Gamestate) {
+			state.KnownCards[1][0] = Guard
+			if state.CardInHand[1] == Guard {
+				state.KnownCards[1][0] = Priest
+			}
+		},
+		"self knowledge": func(state *Gamestate) { state.KnownCards[1][1] = state.CardInHand[1] },
+		"no winner":      func(state *Gamestate) { state.GameEnded = true },
+		"short":          func(state *Gamestate) { state.LastPlay = state.LastPlay[:2] },
+	} {
+		state, _ := NewGame(4, r)
+		assert.NoError(t, state.Validate())
+		breakGame(&state)
+		assert.Error(t, state.Validate(), name)
+	}
+}
+
+func TestValidateEveryPlayPanics(t *testing.T) {
+	ValidateEveryPlay = true
+	defer func() { ValidateEveryPlay = false }()
+
+	state, _ := NewGame(2, r)
+	state.Deck[Guard]++
+	assert.Panics(t, func() {
+		state.PlayCard(state.LegalActions()[0], r)
+	})
+}
@@ -0,0 +1,138 @@
+package rules
+
+// PlayerView is everything one player is allowed to know about a game. It's safe to give to an agent or send to a
+// browser, since it never includes an opponent's hand unless the player has seen it.
+type PlayerView struct {
+	// Player is the id of the player this view is for.
+	Player     int
+	NumPlayers int
+
+	// CardSet is the name of the cards used in the game.
+	CardSet string
+
+	// Hand contains the player's card, followed by their second card if they are the active player.
+	Hand Stack
+
+	// PendingCard is a card the player played that needs another action to resolve (e.g. a Chancellor), or None.
+	// PendingDraws are the cards it drew, which are only shown to the active player.
+	PendingCard  Card
+	PendingDraws Stack
+
+	// LegalActions contains the actions the player may take. It's empty unless it's the player's turn.
+	LegalActions []Action
+
+	ActivePlayer int
+
+	// Faceup, Discards and LastPlay are public, and are the same as in the Gamestate.
+	Faceup   Stack
+	Discards Stacks
+	LastPlay Stack
+
+	// Known contains the player's knowledge of each other player's card (e.g. from a Priest or King), or None.
+	// Once the deck runs out, every remaining hand is revealed.
+	Known Stack
+
+	// Excluded contains the cards the player knows each other player isn't holding, because a guess the player saw was
+	// wrong (see Sampler), in card order.
+	Excluded Stacks
+
+	// Protected is true for each player who can't be targeted (e.g. because their last play was a Handmaid).
+	Protected []bool
+
+	Eliminated []bool
+
+	// DeckSize is the number of cards that can still be drawn, not counting the face-down card.
+	DeckSize int
+
+	// ForcedTarget is the id of a player chosen by a Sycophant, or -1.
+	ForcedTarget int
+
+	GameEnded bool
+	Winner    int
+	Winners   []int
+
+	// BonusTokens contains the tokens each player has earned this game, if any.
+	BonusTokens []int
+
+	// Log contains only the events the player is entitled to see.
+	Log EventLog
+}
+
+// ViewFor returns what the player is allowed to know about the game.
+func (state Gamestate) ViewFor(player int) PlayerView {
+	view := PlayerView{
+		Player:       player,
+		NumPlayers:   state.NumPlayers,
+		CardSet:      state.cards().Name,
+		Hand:         Stack{state.CardInHand[player]},
+		ActivePlayer: state.ActivePlayer,
+		Faceup:       state.Faceup.Copy(),
+		LastPlay:     state.LastPlay.Copy(),
+		Known:        make(Stack, state.NumPlayers),
+		Protected:    make([]bool, state.NumPlayers),
+		Eliminated:   make([]bool, state.NumPlayers),
+		DeckSize:     state.DrawPileSize(),
+		ForcedTarget: state.ForcedTarget,
+		GameEnded:    state.GameEnded,
+		Winner:       state.Winner,
+		Winners:      append([]int(nil), state.Winners...),
+		Log:          state.EventLog.VisibleTo(player),
+	}
+
+	if player == state.ActivePlayer && !state.GameEnded {
+		if state.PendingCard == None {
+			view.Hand = append(view.Hand, state.ActivePlayerCard)
+		}
+		view.PendingCard = state.PendingCard
+		view.PendingDraws = state.PendingDraws.Copy()
+		view.LegalActions = state.LegalActions()
+	}
+
+	view.Discards = make(Stacks, 0, len(state.Discards))
+	for _, discards := range state.Discards {
+		view.Discards = append(view.Discards, discards.Copy())
+	}
+
+	view.Excluded = make(Stacks, state.NumPlayers)
+	for pid, excluded := range state.guessExclusions(player) {
+		if pid == player {
+			continue
+		}
+		for card := Card(0); card < numberOfCards; card++ {
+			if excluded[card] {
+				view.Excluded[pid] = append(view.Excluded[pid], card)
+			}
+		}
+	}
+
+	copy(view.Eliminated, state.EliminatedPlayers)
+	revealed := state.GameEnded && state.remainingPlayers() > 1
+	for pid := range view.Known {
+		view.Protected[pid] = state.isProtected(pid)
+		if pid == player {
+			continue
+		}
+		view.Known[pid] = state.KnownCards[pid][player]
+		if revealed {
+			view.Known[pid] = state.CardInHand[pid]
+		}
+	}
+
+	if state.BonusTokens != nil {
+		view.BonusTokens = make([]int, len(state.BonusTokens))
+		copy(view.BonusTokens, state.BonusTokens)
+	}
+
+	return view
+}
+
+// remainingPlayers returns the number of players who haven't been eliminated.
+func (state Gamestate) remainingPlayers() int {
+	count := 0
+	for _, elim := range state.EliminatedPlayers {
+		if !elim {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewForHidesOpponentCards(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 3)
+	state.EventLog = newEventLog(3)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Prince
+	state.CardInHand[2] = Countess
+	state.ActivePlayerCard = Priest
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 2}, r)
+
+	view := state.ViewFor(0)
+	assert.Equal(t, Stack{Baron}, view.Hand)
+	assert.Equal(t, Stack{None, None, Countess}, view.Known, "Player 0 saw player 2's card with a Priest")
+	assert.Empty(t, view.LegalActions, "It isn't player 0's turn")
+	assert.Equal(t, Stacks{{Priest}, {}, {}}, view.Discards)
+	assert.Equal(t, 2, view.DeckSize)
+	assert.Len(t, view.Log.Events, 3)
+
+	view = state.ViewFor(1)
+	assert.Equal(t, Stack{Prince, state.ActivePlayerCard}, view.Hand)
+	assert.Equal(t, Stack{None, None, None}, view.Known)
+	assert.NotEmpty(t, view.LegalActions)
+	assert.Len(t, view.Log.Events, 2, "Player 1 can't see the Priest reveal")
+}
+
+func TestViewForExcludesFailedGuesses(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 3)
+	state.EventLog = newEventLog(3)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Prince
+	state.CardInHand[2] = Countess
+	state.ActivePlayerCard = Guard
+	state.PlayCard(Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: King}, r)
+
+	assert.Equal(t, Stacks{nil, {King}, nil}, state.ViewFor(0).Excluded)
+	assert.Equal(t, Stacks{nil, nil, nil}, state.ViewFor(1).Excluded, "Players don't exclude their own cards")
+	assert.Equal(t, Stacks{nil, {King}, nil}, state.ViewFor(2).Excluded, "The guess is public")
+}
+
+func TestViewForProtectionAndElimination(t *testing.T) {
+	state := newGame(Deck{Guard: 4}, 3)
+	state.LastPlay[1] = Handmaid
+	state.EliminatedPlayers[2] = true
+
+	view := state.ViewFor(0)
+	assert.Equal(t, []bool{false, true, false}, view.Protected)
+	assert.Equal(t, []bool{false, false, true}, view.Eliminated)
+}
+
+func TestViewForRevealsHandsAtEnd(t *testing.T) {
+	state := newGame(Deck{Guard: 1}, 2)
+	state.CardInHand[0] = Baron
+	state.CardInHand[1] = Prince
+	state.ActivePlayerCard = Handmaid
+	state.PlayCard(Action{PlayRecent: true}, r)
+
+	assert.True(t, state.GameEnded)
+	view := state.ViewFor(0)
+	assert.Equal(t, Stack{None, Prince}, view.Known)
+	assert.Empty(t, view.LegalActions)
+}
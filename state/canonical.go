@@ -0,0 +1,49 @@
+package state
+
+import (
+	"love-letter-ai/rules"
+)
+
+// CanonicalEncoder encodes views as if the cards in hand were ordered with the recent card no lower than the old card,
+// so a hand and its mirror (e.g. a Guard and a Priest, drawn in either order) share a state and learn from each other.
+// Actions are mirrored too, so playing the recent card of a swapped hand is encoded as playing the old card.
+// The states it never uses aren't removed, so tables aren't any smaller unless they're sparse (see CanonicalCompact).
+type CanonicalEncoder struct {
+	Encoder StateEncoder
+}
+
+func (enc CanonicalEncoder) Name() string { return "canonical-" + enc.Encoder.Name() }
+func (enc CanonicalEncoder) Size() int    { return enc.Encoder.Size() }
+
+func (enc CanonicalEncoder) Index(view rules.PlayerView) int {
+	return enc.Encoder.Index(CanonicalView(view))
+}
+
+func (enc CanonicalEncoder) Features(view rules.PlayerView) []float32 {
+	return enc.Encoder.Features(CanonicalView(view))
+}
+
+// Mirrored returns true if the cards in the view's hand are swapped.
+func (CanonicalEncoder) Mirrored(view rules.PlayerView) bool {
+	return mirroredHand(view)
+}
+
+// CanonicalView returns the view with the cards in hand swapped if the recent card is lower than the old card.
+// The view is copied rather than changed.
+func CanonicalView(view rules.PlayerView) rules.PlayerView {
+	if mirroredHand(view) {
+		view.Hand = []rules.Card{view.Hand[1], view.Hand[0]}
+	}
+	return view
+}
+
+// mirroredHand returns true if the active player's recent card is lower than their old card.
+func mirroredHand(view rules.PlayerView) bool {
+	return len(view.Hand) == 2 && view.Hand[1] < view.Hand[0]
+}
+
+// IsCanonical returns true if the Simple state index (see Index) has the cards in hand in canonical order.
+func IsCanonical(st int) bool {
+	_, recent, old, _, _ := FromIndex(st)
+	return recent >= old
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"math/rand"
+	"testing"
+
+	"love-letter-ai/rules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalEncodersShareMirroredHands(t *testing.T) {
+	drawn, err := rules.ParseScenario("p0: hand=Guard drawn=Priest; p1: hand=King; faceup=Baron,Guard,Guard")
+	assert.NoError(t, err)
+	kept, err := rules.ParseScenario("p0: hand=Priest drawn=Guard; p1: hand=King; faceup=Baron,Guard,Guard")
+	assert.NoError(t, err)
+	playPriest := rules.Action{PlayRecent: true, TargetPlayerOffset: 1}
+
+	assert.NotEqual(t, SimpleEncoder{}.Index(drawn.ViewFor(0)), SimpleEncoder{}.Index(kept.ViewFor(0)))
+	for _, enc := range []StateEncoder{CanonicalEncoder{SimpleEncoder{}}, CanonicalEncoder{KnowledgeEncoder{}}, CanonicalCompact} {
+		assert.Equal(t, enc.Index(drawn.ViewFor(0)), enc.Index(kept.ViewFor(0)), enc.Name())
+		assert.Equal(t, enc.Features(drawn.ViewFor(0)), enc.Features(kept.ViewFor(0)), enc.Name())
+
+		// Playing the Priest is the same state-action, whichever card it is
+		sa, _ := EncodeAction(enc, drawn.ViewFor(0), playPriest)
+		mirror := playPriest
+		mirror.PlayRecent = false
+		mirrorSA, _ := EncodeAction(enc, kept.ViewFor(0), mirror)
+		assert.Equal(t, sa, mirrorSA, enc.Name())
+		assert.Equal(t, EncodedMask(enc, drawn.ViewFor(0)), EncodedMask(enc, kept.ViewFor(0)), enc.Name())
+	}
+}
+
+func TestCanonicalActionsRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	encoders := []StateEncoder{SimpleEncoder{}, CanonicalEncoder{SimpleEncoder{}}, CanonicalEncoder{KnowledgeEncoder{}}}
+	for i := 0; i < 500; i++ {
+		game, err := rules.NewGame(2, r)
+		assert.NoError(t, err)
+		for !game.GameEnded {
+			view := game.ViewFor(game.ActivePlayer)
+			for _, enc := range encoders {
+				mask := EncodedMask(enc, view)
+				for _, act := range game.LegalActions() {
+					sa, st := EncodeAction(enc, view, act)
+					encoded := (sa - st) / enc.Size()
+					assert.True(t, mask.Has(encoded), "%s: %+v isn't in the mask", enc.Name(), act)
+					decoded := DecodeAction(enc, view, rules.ActionFromInt(encoded))
+					assert.Equal(t, act.PlayRecent, decoded.PlayRecent, enc.Name())
+					again, _ := EncodeAction(enc, view, decoded)
+					assert.Equal(t, sa, again, enc.Name())
+				}
+			}
+
+			acts := game.LegalActions()
+			game.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+	}
+}
+
+func TestMirrorMask(t *testing.T) {
+	for recent := rules.Guard; recent <= rules.Princess; recent++ {
+		for old := rules.Guard; old <= rules.Princess; old++ {
+			assert.Equal(t, LegalMask(old, recent), LegalMask(recent, old).Mirror(), "%v, %v", recent, old)
+		}
+	}
+	assert.Equal(t, AllActions, AllActions.Mirror())
+}
+
+func TestCanonicalCompactIsAboutHalf(t *testing.T) {
+	assert.True(t, IsCanonical(Index(rules.Deck{}, rules.Priest, rules.Guard, rules.King, 0)))
+	assert.False(t, IsCanonical(Index(rules.Deck{}, rules.Guard, rules.Priest, rules.King, 0)))
+
+	size := CanonicalCompact.Size()
+	assert.True(t, size < Compact.Size()*6/10 && size > Compact.Size()*4/10, "%d of %d states", size, Compact.Size())
+	for st := 0; st < size-2; st++ {
+		simple, ok := CanonicalCompact.SimpleIndex(st)
+		if !ok || !IsCanonical(simple) {
+			t.Fatalf("State %d isn't canonical", st)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package state
+
+import (
+	"sync"
+
+	"love-letter-ai/perfecthash"
+	"love-letter-ai/rules"
+)
+
+// ReachableStates returns every Simple state index (see Index) that can be reached with the classic cards.
+// It includes some states that can't be reached, since it only checks that:
+//   - the discards and the cards in hand aren't more than the deck has, and leave a card for the opponent;
+//   - the opponent's last play was discarded (or it's the Princess, if they haven't discarded, or a Guard, if their
+//     cards were only discarded by a Prince);
+//   - the score difference is the value of some discards minus the value of others.
+func ReachableStates() []uint32 {
+	full := rules.ClassicCards.Deck()
+	states := []uint32{}
+	for d := 0; d < rules.DeckSpaceMagnitude; d++ {
+		discards := rules.Deck{}
+		discards.FromInt(d)
+		if discards.Size()+3 > full.Size() {
+			continue
+		}
+		scores := reachableScores(discards)
+
+		opponents := [rules.Princess + 1]bool{}
+		opponents[rules.Guard] = true
+		opponents[rules.Princess] = true
+		for card := rules.Guard; card < rules.Princess; card++ {
+			opponents[card] = opponents[card] || discards[card] > 0
+		}
+
+		for old := rules.Guard; old <= rules.Princess; old++ {
+			for recent := rules.Guard; recent <= rules.Princess; recent++ {
+				held := discards
+				held[old]++
+				held[recent]++
+				if held[old] > full[old] || held[recent] > full[recent] {
+					continue
+				}
+
+				for opponent := rules.Guard; opponent <= rules.Princess; opponent++ {
+					if !opponents[opponent] {
+						continue
+					}
+					for _, score := range scores {
+						states = append(states, uint32(Index(discards, recent, old, opponent, score)))
+					}
+				}
+			}
+		}
+	}
+	return states
+}
+
+// reachableScores returns every score difference (limited as in Index) that could come from splitting the discards
+// between the player, the opponent, and cards neither of them discarded.
+func reachableScores(discards rules.Deck) []int {
+	const offset = 64 // Larger than the value of every card
+	possible := [2 * offset]bool{}
+	possible[offset] = true
+	for card := rules.Guard; card <= rules.Princess; card++ {
+		for n := 0; n < discards[card]; n++ {
+			next := possible
+			for diff, ok := range possible {
+				if ok {
+					next[diff+int(card)] = true
+					next[diff-int(card)] = true
+				}
+			}
+			possible = next
+		}
+	}
+
+	seen := map[int]bool{}
+	scores := []int{}
+	for diff, ok := range possible {
+		score := scoreFromValue(scoreValue(diff - offset))
+		if ok && !seen[score] {
+			seen[score] = true
+			scores = append(scores, score)
+		}
+	}
+	return scores
+}
+
+// CompactEncoder encodes the same states as SimpleEncoder, but numbers only the ReachableStates, using a perfect hash,
+// so tables indexed by it are much smaller. Any state that isn't one of them (which shouldn't happen) shares the
+// second last state. As with state.TerminalState, the last state is never reached.
+// The hash is built the first time it's needed, which takes a few seconds, and always numbers the states the same.
+type CompactEncoder struct {
+	once sync.Once
+	hash *perfecthash.Hash
+
+	// canonical numbers only the states with the cards in hand in canonical order, as in CanonicalEncoder.
+	canonical bool
+}
+
+// Compact is the shared CompactEncoder, so the hash is only built once.
+var Compact = &CompactEncoder{}
+
+// CanonicalCompact is the shared CompactEncoder that mirrors hands like CanonicalEncoder, so it numbers about half as
+// many states as Compact.
+var CanonicalCompact = &CompactEncoder{canonical: true}
+
+func (enc *CompactEncoder) Name() string {
+	if enc.canonical {
+		return "canonical-compact"
+	}
+	return "compact"
+}
+
+func (enc *CompactEncoder) Size() int {
+	return enc.Hash().Len() + 2
+}
+
+// Hash returns the perfect hash from Simple state indices to compact states.
+func (enc *CompactEncoder) Hash() *perfecthash.Hash {
+	enc.once.Do(func() {
+		states := ReachableStates()
+		if enc.canonical {
+			kept := states[:0]
+			for _, st := range states {
+				if IsCanonical(int(st)) {
+					kept = append(kept, st)
+				}
+			}
+			states = kept
+		}
+		enc.hash = perfecthash.New(states)
+	})
+	return enc.hash
+}
+
+func (enc *CompactEncoder) Index(view rules.PlayerView) int {
+	if enc.canonical {
+		view = CanonicalView(view)
+	}
+	hash := enc.Hash()
+	if st, ok := hash.Lookup(uint32(SimpleEncoder{}.Index(view))); ok {
+		return st
+	}
+	return hash.Len()
+}
+
+// Features returns the same features as SimpleEncoder (or CanonicalEncoder, if the hands are mirrored).
+func (enc *CompactEncoder) Features(view rules.PlayerView) []float32 {
+	if enc.canonical {
+		view = CanonicalView(view)
+	}
+	return SimpleEncoder{}.Features(view)
+}
+
+// Mirrored returns true if the cards in the view's hand are swapped, which only the CanonicalCompact encoder does.
+func (enc *CompactEncoder) Mirrored(view rules.PlayerView) bool {
+	return enc.canonical && mirroredHand(view)
+}
+
+// SimpleIndex returns the Simple state index (see Index) for the compact state, or false for the last two states.
+func (enc *CompactEncoder) SimpleIndex(st int) (int, bool) {
+	hash := enc.Hash()
+	if st >= hash.Len() {
+		return 0, false
+	}
+	return int(hash.Key(st)), true
+}
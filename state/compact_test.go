@@ -0,0 +1,65 @@
+package state
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"love-letter-ai/rules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactEncoderReachesEveryState(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	enc := Compact
+	unreachable := enc.Size() - 2
+	for i := 0; i < 20000; i++ {
+		game, err := rules.NewGame(2+i%3, r)
+		assert.NoError(t, err)
+		for !game.GameEnded {
+			view := game.ViewFor(game.ActivePlayer)
+			st := enc.Index(view)
+			if st == unreachable {
+				t.Fatalf("The state wasn't reachable:\n%s", game.Scenario())
+			}
+			simple, ok := enc.SimpleIndex(st)
+			assert.True(t, ok)
+			assert.Equal(t, SimpleEncoder{}.Index(view), simple)
+
+			acts := game.LegalActions()
+			game.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+	}
+}
+
+func TestReachableStates(t *testing.T) {
+	states := ReachableStates()
+	assert.True(t, len(states) < SpaceMagnitude/4, "%d states are reachable", len(states))
+	assert.Equal(t, len(states)+2, Compact.Size())
+
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	for i := 1; i < len(states); i++ {
+		if states[i] == states[i-1] {
+			t.Fatalf("State %d is repeated", states[i])
+		}
+	}
+
+	// Holding two Princesses, or both Kings, is impossible
+	_, ok := Compact.Hash().Lookup(uint32(Index(rules.Deck{}, rules.Princess, rules.Princess, rules.Guard, 0)))
+	assert.False(t, ok)
+	_, ok = Compact.Hash().Lookup(uint32(TerminalState))
+	assert.False(t, ok)
+
+	// With nothing discarded, the score must be tied
+	_, ok = Compact.Hash().Lookup(uint32(Index(rules.Deck{}, rules.Guard, rules.Baron, rules.Princess, 0)))
+	assert.True(t, ok)
+	_, ok = Compact.Hash().Lookup(uint32(Index(rules.Deck{}, rules.Guard, rules.Baron, rules.Princess, 2)))
+	assert.False(t, ok)
+}
+
+func TestReachableScores(t *testing.T) {
+	assert.Equal(t, []int{0}, reachableScores(rules.Deck{}))
+	assert.ElementsMatch(t, []int{-3, -2, -1, 0, 1, 2, 3}, reachableScores(rules.Deck{rules.Guard: 1, rules.Priest: 1}))
+	assert.Len(t, reachableScores(rules.ClassicCards.Deck()), 31)
+}
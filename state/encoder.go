@@ -0,0 +1,216 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"love-letter-ai/rules"
+)
+
+// StateEncoder converts what the active player can see into a state, so tabular agents can index their values by it.
+// Actions are indexed by rules.Action.AsInt, as if the opponent (see SimpleFromView) were the next player.
+type StateEncoder interface {
+	// Name identifies the encoder, e.g. on the command line.
+	Name() string
+
+	// Size returns the number of states. Index always returns a number between 0 and Size()-1.
+	Size() int
+
+	// Index returns the state for the active player's view.
+	Index(view rules.PlayerView) int
+
+	// Features returns the state as a vector of numbers, e.g. for function approximation.
+	Features(view rules.PlayerView) []float32
+}
+
+// ActionSize returns the number of state-actions for the encoder.
+func ActionSize(enc StateEncoder) int {
+	return 16 * enc.Size()
+}
+
+// Mirror is implemented by encoders that encode some views as if the two cards in hand were swapped, so mirrored
+// hands share a state. Actions for that state play the other card (see EncodeAction and DecodeAction).
+type Mirror interface {
+	Mirrored(view rules.PlayerView) bool
+}
+
+// mirrored returns true if the encoder swaps the cards in the view's hand.
+func mirrored(enc StateEncoder, view rules.PlayerView) bool {
+	m, ok := enc.(Mirror)
+	return ok && m.Mirrored(view)
+}
+
+// EncodeAction returns the state-action and the state for the active player's view and action.
+// An action targeting the opponent is indexed as if the opponent's offset were 1.
+func EncodeAction(enc StateEncoder, view rules.PlayerView, act rules.Action) (int, int) {
+	st := enc.Index(view)
+	return st + encodeAct(enc, view, act)*enc.Size(), st
+}
+
+// encodeAct returns the action's index (see rules.Action.AsInt) for the encoder's state of the view.
+func encodeAct(enc StateEncoder, view rules.PlayerView, act rules.Action) int {
+	if act.TargetPlayerOffset == opponentOffset(view) {
+		act.TargetPlayerOffset = 1
+	}
+	if mirrored(enc, view) {
+		act.PlayRecent = !act.PlayRecent
+	}
+	return act.AsInt()
+}
+
+// ActionStates returns every state-action for the state, indexed by rules.Action.AsInt. As with AllActionStates, some
+// of them are impossible.
+func ActionStates(enc StateEncoder, st int) [16]int {
+	states := [16]int{}
+	for i := range states {
+		states[i] = st + i*enc.Size()
+	}
+	return states
+}
+
+// TargetOpponent converts an action that targets offset 1 (as all actions from an index do) into an action that
+// targets the opponent in the view.
+func TargetOpponent(view rules.PlayerView, act rules.Action) rules.Action {
+	if act.TargetPlayerOffset == 1 {
+		act.TargetPlayerOffset = opponentOffset(view)
+	}
+	return act
+}
+
+// DecodeAction converts an action for the encoder's state of the view (e.g. from ActionStates) into an action in the
+// view. It reverses EncodeAction.
+func DecodeAction(enc StateEncoder, view rules.PlayerView, act rules.Action) rules.Action {
+	if mirrored(enc, view) {
+		act.PlayRecent = !act.PlayRecent
+	}
+	return TargetOpponent(view, act)
+}
+
+// Encoders contains every StateEncoder, by name.
+var Encoders = map[string]StateEncoder{
+	SimpleEncoder{}.Name():                      SimpleEncoder{},
+	KnowledgeEncoder{}.Name():                   KnowledgeEncoder{},
+	Compact.Name():                              Compact,
+	CanonicalEncoder{SimpleEncoder{}}.Name():    CanonicalEncoder{SimpleEncoder{}},
+	CanonicalEncoder{KnowledgeEncoder{}}.Name(): CanonicalEncoder{KnowledgeEncoder{}},
+	CanonicalCompact.Name():                     CanonicalCompact,
+}
+
+// EncoderNames returns the names of every StateEncoder, in alphabetical order.
+func EncoderNames() []string {
+	names := make([]string, 0, len(Encoders))
+	for name := range Encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EncoderNamed returns the StateEncoder with the name.
+func EncoderNamed(name string) (StateEncoder, error) {
+	enc, ok := Encoders[name]
+	if !ok {
+		return nil, fmt.Errorf("There is no state encoder named '%s'", name)
+	}
+	return enc, nil
+}
+
+// SimpleEncoder encodes the same state as Simple.AsIndex, so it can use values trained before there were encoders.
+type SimpleEncoder struct{}
+
+func (SimpleEncoder) Name() string { return "simple" }
+func (SimpleEncoder) Size() int    { return SpaceMagnitude }
+
+// Index returns Simple.AsIndex. If the opponent's cards were only discarded by others (e.g. with a Prince), they have
+// no last play, and it's encoded as a Guard, as in a Trace.
+func (SimpleEncoder) Index(view rules.PlayerView) int {
+	simple := SimpleFromView(view)
+	if simple.OpponentCard == rules.None {
+		simple.OpponentCard = rules.Guard
+	}
+	return simple.AsIndex()
+}
+
+// Features returns the number of each card discarded, followed by the cards in hand, the opponent's last play and the
+// score difference.
+func (SimpleEncoder) Features(view rules.PlayerView) []float32 {
+	simple := SimpleFromView(view)
+	return append(discardFeatures(simple.Discards),
+		float32(simple.RecentDraw), float32(simple.OldCard), float32(simple.OpponentCard), float32(simple.ScoreDiff))
+}
+
+// KnowledgeEncoder encodes what the player knows about the opponent's card (e.g. from a Priest or King) and whether
+// the opponent is protected, instead of the opponent's last play. Only the sign of the score difference is kept, since
+// that's all that matters if the game ends in a tie. Like Simple, it assumes the classic cards.
+//
+// The order of the discards is left out, as in Simple. So are the cards a failed guess excluded from the opponent's
+// hand (see rules.PlayerView.Excluded) from Index: in a 2-player game the opponent always plays a card after the
+// player's guess, which undoes it, and in larger games it would multiply the states by 128. Features includes them.
+type KnowledgeEncoder struct{}
+
+func (KnowledgeEncoder) Name() string { return "knowledge" }
+
+func (KnowledgeEncoder) Size() int {
+	return rules.DeckSpaceMagnitude * 8 * 8 * 9 * 2 * 3
+}
+
+func (KnowledgeEncoder) Index(view rules.PlayerView) int {
+	simple := SimpleFromView(view)
+	known, protected, score := knowledge(view, simple)
+	index := simple.Discards.AsInt()
+	index = index*8 + int(simple.RecentDraw-1)
+	index = index*8 + int(simple.OldCard-1)
+	index = index*9 + int(known)
+	index = index*2 + protected
+	return index*3 + score
+}
+
+// Features returns the number of each card discarded, followed by the cards in hand, the opponent's known card (or 0),
+// whether they're protected (1 or 0), the sign of the score difference (0 if behind, 1 if tied or 2 if ahead) and
+// whether each card has been excluded from the opponent's hand (1 or 0).
+func (KnowledgeEncoder) Features(view rules.PlayerView) []float32 {
+	simple := SimpleFromView(view)
+	known, protected, score := knowledge(view, simple)
+	features := append(discardFeatures(simple.Discards),
+		float32(simple.RecentDraw), float32(simple.OldCard), float32(known), float32(protected), float32(score))
+	return append(features, exclusionFeatures(view, simple)...)
+}
+
+// knowledge returns the opponent's known card (or None), 1 if they're protected, and the sign of the score difference.
+func knowledge(view rules.PlayerView, simple Simple) (rules.Card, int, int) {
+	opponent := (view.Player + simple.OpponentOffset) % view.NumPlayers
+	protected := 0
+	if view.Protected[opponent] {
+		protected = 1
+	}
+	score := 1
+	if simple.ScoreDiff < 0 {
+		score = 0
+	} else if simple.ScoreDiff > 0 {
+		score = 2
+	}
+	return view.Known[opponent], protected, score
+}
+
+// exclusionFeatures returns 1 for each classic card that has been excluded from the opponent's hand, and 0 otherwise.
+func exclusionFeatures(view rules.PlayerView, simple Simple) []float32 {
+	opponent := (view.Player + simple.OpponentOffset) % view.NumPlayers
+	features := make([]float32, rules.Princess)
+	if opponent < len(view.Excluded) {
+		for _, card := range view.Excluded[opponent] {
+			if card >= rules.Guard && card <= rules.Princess {
+				features[card-rules.Guard] = 1
+			}
+		}
+	}
+	return features
+}
+
+// discardFeatures returns the number of each classic card that was discarded.
+func discardFeatures(discards rules.Deck) []float32 {
+	features := make([]float32, 0, rules.Princess+4)
+	for card := rules.Guard; card <= rules.Princess; card++ {
+		features = append(features, float32(discards[card]))
+	}
+	return features
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"love-letter-ai/rules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFromViewMatchesNewSimple(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 30; i++ {
+		game, err := rules.NewGame(2+i%3, r)
+		assert.NoError(t, err)
+		for !game.GameEnded {
+			view := game.ViewFor(game.ActivePlayer)
+			simple := NewSimple(game)
+			assert.Equal(t, simple, SimpleFromView(view))
+			if simple.OpponentCard != rules.None {
+				assert.Equal(t, simple.AsIndex(), SimpleEncoder{}.Index(view))
+			}
+
+			acts := game.LegalActions()
+			act := acts[r.Intn(len(acts))]
+			expected, _ := simple.AsIndexWithAction(act)
+			sa, _ := EncodeAction(SimpleEncoder{}, view, act)
+			if simple.OpponentCard != rules.None {
+				assert.Equal(t, expected, sa)
+			}
+
+			for _, enc := range Encoders {
+				sa, st := EncodeAction(enc, view, act)
+				assert.True(t, st >= 0 && st < enc.Size(), enc.Name())
+				assert.Contains(t, ActionStates(enc, st), sa, enc.Name())
+			}
+			game.PlayCard(act, r)
+		}
+	}
+}
+
+func TestKnowledgeEncoder(t *testing.T) {
+	unknown, err := rules.ParseScenario("p0: hand=Guard drawn=Priest; p1: hand=King; faceup=Baron,Guard,Guard")
+	assert.NoError(t, err)
+	known, err := rules.ParseScenario("p0: hand=Guard drawn=Priest knows=p1:King; p1: hand=King; faceup=Baron,Guard,Guard")
+	assert.NoError(t, err)
+
+	enc := KnowledgeEncoder{}
+	assert.NotEqual(t, enc.Index(unknown.ViewFor(0)), enc.Index(known.ViewFor(0)))
+	assert.Equal(t, SimpleEncoder{}.Index(unknown.ViewFor(0)), SimpleEncoder{}.Index(known.ViewFor(0)), "Simple states ignore knowledge")
+
+	features := enc.Features(known.ViewFor(0))
+	assert.Equal(t, []float32{2, 0, 1, 0, 0, 0, 0, 0, float32(rules.Priest), float32(rules.Guard), float32(rules.King), 0, 1,
+		0, 0, 0, 0, 0, 0, 0, 0}, features)
+	assert.Len(t, SimpleEncoder{}.Features(known.ViewFor(0)), 12)
+
+	protected, err := rules.ParseScenario("p0: hand=Guard drawn=Priest; p1: hand=King discards=Handmaid; faceup=Baron,Guard,Guard")
+	assert.NoError(t, err)
+	assert.NotEqual(t, enc.Index(unknown.ViewFor(0)), enc.Index(protected.ViewFor(0)))
+
+	// Player 2's wrong guess tells player 0 that their opponent, player 1, doesn't have a King
+	guessed, err := rules.ParseScenario("active=2; p0: hand=Guard; p1: hand=Priest; p2: hand=Baron drawn=Guard")
+	assert.NoError(t, err)
+	guessed.PlayCard(rules.Action{PlayRecent: true, TargetPlayerOffset: 2, SelectedCard: rules.King}, rand.New(rand.NewSource(1)))
+	view := guessed.ViewFor(0)
+	assert.Equal(t, rules.Stack{rules.King}, view.Excluded[1])
+	excluded := enc.Features(view)[13:]
+	assert.Equal(t, []float32{0, 0, 0, 0, 0, 1, 0, 0}, excluded)
+}
+
+func TestEncoderNamed(t *testing.T) {
+	enc, err := EncoderNamed("knowledge")
+	assert.NoError(t, err)
+	assert.Equal(t, KnowledgeEncoder{}, enc)
+	_, err = EncoderNamed("missing")
+	assert.Error(t, err)
+
+	names := EncoderNames()
+	assert.Len(t, names, len(Encoders))
+	assert.True(t, sort.StringsAreSorted(names))
+	for _, name := range names {
+		_, err := EncoderNamed(name)
+		assert.NoError(t, err)
+	}
+}
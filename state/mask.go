@@ -0,0 +1,125 @@
+package state
+
+import (
+	"math/bits"
+	"math/rand"
+
+	"love-letter-ai/rules"
+)
+
+// ActionMask has a bit set for each action (see rules.Action.AsInt) that may be chosen.
+type ActionMask uint16
+
+// AllActions allows every action, including impossible ones.
+const AllActions = ActionMask(0xFFFF)
+
+// LegalMask returns the actions the classic rules allow when holding the cards, as if the opponent were the next player.
+// It only knows the cards, so it includes targeting the opponent even if they're protected, which the rules don't allow
+// while another player (including, for a Prince, its own player) can be targeted. ViewMask knows the whole game.
+func LegalMask(recent, old rules.Card) ActionMask {
+	return legalMaskForCard(true, recent, old) | legalMaskForCard(false, old, recent)
+}
+
+// LegalMask returns the actions allowed for the cards in hand (see LegalMask).
+func (ss Simple) LegalMask() ActionMask {
+	return LegalMask(ss.RecentDraw, ss.OldCard)
+}
+
+// ViewMask returns the legal actions in the active player's view (see rules.PlayerView.LegalActions).
+func ViewMask(view rules.PlayerView) ActionMask {
+	return EncodedMask(SimpleEncoder{}, view)
+}
+
+// EncodedMask returns the legal actions in the view, encoded for the encoder's state (see EncodeAction), so they're
+// mirrored if the encoder swaps the cards in hand. Actions that target an opponent other than the encoded one can't be
+// encoded, so they're left out. If that leaves nothing, or the view has no legal actions, the actions allowed for the
+// cards in hand (see LegalMask) are returned instead.
+func EncodedMask(enc StateEncoder, view rules.PlayerView) ActionMask {
+	opponent := opponentOffset(view)
+	mask := ActionMask(0)
+	for _, act := range view.LegalActions {
+		if act.TargetPlayerOffset == 0 || act.TargetPlayerOffset == opponent {
+			mask |= 1 << uint(encodeAct(enc, view, act))
+		}
+	}
+	if mask != 0 {
+		return mask
+	}
+
+	mask = SimpleFromView(view).LegalMask()
+	if mirrored(enc, view) {
+		return mask.Mirror()
+	}
+	return mask
+}
+
+// legalMaskForCard returns the legal actions when playing card while keeping other.
+func legalMaskForCard(isRecent bool, card, other rules.Card) ActionMask {
+	cards := rules.ClassicCards
+	def := cards.Definition(card)
+	if def == nil {
+		return 0
+	}
+	if kept := cards.Definition(other); kept != nil && kept.MustBePlayedInsteadOf(card) {
+		return 0
+	}
+
+	acts := []rules.Action{}
+	switch def.Targeting() {
+	case rules.NoTarget:
+		acts = append(acts, rules.Action{PlayRecent: isRecent})
+	case rules.TargetOpponent:
+		acts = append(acts, rules.Action{PlayRecent: isRecent, TargetPlayerOffset: 1})
+	case rules.TargetAnyPlayer:
+		acts = append(acts, rules.Action{PlayRecent: isRecent}, rules.Action{PlayRecent: isRecent, TargetPlayerOffset: 1})
+	case rules.GuessOpponent:
+		for _, guess := range cards.Cards() {
+			if guess != card {
+				acts = append(acts, rules.Action{PlayRecent: isRecent, TargetPlayerOffset: 1, SelectedCard: guess})
+			}
+		}
+	}
+
+	mask := ActionMask(0)
+	for _, act := range acts {
+		mask |= 1 << uint(act.AsInt())
+	}
+	return mask
+}
+
+// Has returns true if the action (see rules.Action.AsInt) is in the mask.
+func (mask ActionMask) Has(act int) bool {
+	return mask&(1<<uint(act)) != 0
+}
+
+// Mirror returns the mask with the card played swapped in each action, i.e. with rules.Action.PlayRecent flipped.
+func (mask ActionMask) Mirror() ActionMask {
+	const old = 0x5555 // The actions without PlayRecent set, which is the lowest bit
+	return (mask&old)<<1 | (mask>>1)&old
+}
+
+// Count returns the number of actions in the mask.
+func (mask ActionMask) Count() int {
+	return bits.OnesCount16(uint16(mask))
+}
+
+// Random returns one of the actions in the mask, chosen uniformly, or -1 if it's empty.
+// If r is nil, the default source is used.
+func (mask ActionMask) Random(r *rand.Rand) int {
+	if mask == 0 {
+		return -1
+	}
+	intn := rand.Intn
+	if r != nil {
+		intn = r.Intn
+	}
+	n := intn(mask.Count())
+	for act := 0; ; act++ {
+		if mask.Has(act) {
+			if n == 0 {
+				return act
+			}
+			n--
+		}
+	}
+}
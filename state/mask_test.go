@@ -0,0 +1,72 @@
+package state
+
+import (
+	"math/rand"
+	"testing"
+
+	"love-letter-ai/rules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegalMask(t *testing.T) {
+	// Guard guesses, and playing the Princess
+	assert.Equal(t, 8, LegalMask(rules.Guard, rules.Princess).Count())
+	assert.Equal(t, 14, LegalMask(rules.Guard, rules.Guard).Count())
+	// Only the Countess can be played with a King
+	assert.Equal(t, 1, LegalMask(rules.King, rules.Countess).Count())
+	assert.True(t, LegalMask(rules.King, rules.Countess).Has(rules.Action{PlayRecent: false}.AsInt()))
+	// A Prince can target either player
+	assert.Equal(t, 3, LegalMask(rules.Prince, rules.Handmaid).Count())
+
+	r := rand.New(rand.NewSource(12))
+	for i := 0; i < 2000; i++ {
+		game, err := rules.NewGame(2+i%3, r)
+		assert.NoError(t, err)
+		for !game.GameEnded {
+			view := game.ViewFor(game.ActivePlayer)
+			mask := ViewMask(view)
+			opponent := opponentOffset(view)
+
+			encodable := false
+			for _, act := range game.LegalActions() {
+				if act.TargetPlayerOffset != 0 && act.TargetPlayerOffset != opponent {
+					// Other opponents can't be encoded
+					continue
+				}
+				encodable = true
+				sa, st := EncodeAction(SimpleEncoder{}, view, act)
+				assert.True(t, mask.Has((sa-st)/SpaceMagnitude), "%+v isn't in the mask", act)
+			}
+
+			handMask := SimpleFromView(view).LegalMask()
+			for act := 0; act < 16; act++ {
+				if !mask.Has(act) {
+					continue
+				}
+				assert.True(t, handMask.Has(act), "Legal actions are allowed for the cards in hand")
+				action := TargetOpponent(view, rules.ActionFromInt(act))
+				if err := game.CheckAction(action); err != nil {
+					// Only when every legal action targets an opponent who can't be encoded
+					assert.False(t, encodable, "%+v: %v", action, err)
+					assert.NotEqual(t, 2, game.NumPlayers)
+				}
+			}
+
+			game.PlayCard(TargetOpponent(view, rules.ActionFromInt(mask.Random(r))), r)
+		}
+	}
+}
+
+func TestRandomMaskAction(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	assert.Equal(t, -1, ActionMask(0).Random(r))
+	counts := map[int]int{}
+	for i := 0; i < 3000; i++ {
+		counts[ActionMask(1<<3|1<<9|1<<15).Random(r)]++
+	}
+	assert.Len(t, counts, 3)
+	for _, count := range counts {
+		assert.InDelta(t, 1000, count, 150)
+	}
+}
@@ -2,7 +2,8 @@ package state
 
 import "love-letter-ai/rules"
 
-// Simple provides a simplified state. It is only valid for 2 players.
+// Simple provides a simplified state. It treats the game as if there were only one opponent.
+// With more than 2 players, the opponent is the next player (in turn order) who is still in the game.
 type Simple struct {
 	// Discards is all of the cards discarded so far (unsorted, unattributed)
 	Discards rules.Deck
@@ -18,6 +19,10 @@ type Simple struct {
 
 	// ScoreDiff is the current player's score lead compared to the opponent
 	ScoreDiff int
+
+	// OpponentOffset is the TargetPlayerOffset of the opponent. It is always 1 for 2 players.
+	// This is not included in the index.
+	OpponentOffset int
 }
 
 // Simple converts a rules.Gamestate to a Simple
@@ -29,10 +34,8 @@ func NewSimple(gs rules.Gamestate) Simple {
 	simple.OldCard = gs.CardInHand[gs.ActivePlayer]
 
 	// Figure out opponent's ID
-	opponent := 0
-	if gs.ActivePlayer == 0 {
-		opponent = 1
-	}
+	opponent := gs.NextPlayer(gs.ActivePlayer)
+	simple.OpponentOffset = (opponent - gs.ActivePlayer + gs.NumPlayers) % gs.NumPlayers
 	if len(gs.Discards[opponent]) > 0 {
 		// Get opponent's last played card. (If a Prince was played on the opponent, this will still show the last played card.)
 		simple.OpponentCard = gs.LastPlay[opponent]
@@ -51,6 +54,57 @@ func (ss Simple) AsIndex() int {
 }
 
 // AsIndexWithAction converts the simple state and action into an array index.
+// An action targeting the opponent is indexed as if the opponent's offset were 1.
 func (ss Simple) AsIndexWithAction(act rules.Action) (int, int) {
+	if act.TargetPlayerOffset == ss.OpponentOffset {
+		act.TargetPlayerOffset = 1
+	}
 	return Indices(ss.Discards, ss.RecentDraw, ss.OldCard, ss.OpponentCard, ss.ScoreDiff, act)
 }
+
+// TargetOpponent converts an action that targets offset 1 (as all actions from an index do) into an action that
+// targets the opponent.
+func (ss Simple) TargetOpponent(act rules.Action) rules.Action {
+	if act.TargetPlayerOffset == 1 {
+		act.TargetPlayerOffset = ss.OpponentOffset
+	}
+	return act
+}
+
+// SimpleFromView converts the active player's view to a Simple. It's the same as NewSimple for the game.
+func SimpleFromView(view rules.PlayerView) Simple {
+	simple := Simple{}
+
+	simple.Discards = view.Faceup.AsDeck()
+	for _, discards := range view.Discards {
+		simple.Discards.AddStack(discards)
+	}
+	simple.OldCard = view.Hand[0]
+	if len(view.Hand) > 1 {
+		simple.RecentDraw = view.Hand[1]
+	} else {
+		// The active player's second card is pending, so it has already been discarded
+		simple.RecentDraw = view.PendingCard
+	}
+
+	simple.OpponentOffset = opponentOffset(view)
+	opponent := (view.Player + simple.OpponentOffset) % view.NumPlayers
+	if len(view.Discards[opponent]) > 0 {
+		simple.OpponentCard = view.LastPlay[opponent]
+	} else {
+		simple.OpponentCard = rules.Princess
+	}
+	simple.ScoreDiff = view.Discards[view.Player].Score() - view.Discards[opponent].Score()
+
+	return simple
+}
+
+// opponentOffset returns the offset of the next player (in turn order) who is still in the game.
+func opponentOffset(view rules.PlayerView) int {
+	for offset := 1; offset < view.NumPlayers; offset++ {
+		if !view.Eliminated[(view.Player+offset)%view.NumPlayers] {
+			return offset
+		}
+	}
+	return 0
+}
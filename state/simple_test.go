@@ -0,0 +1,37 @@
+package state
+
+import (
+	"math/rand"
+	"testing"
+
+	"love-letter-ai/rules"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleOpponentSkipsEliminated(t *testing.T) {
+	gs, err := rules.NewGame(4, rand.New(rand.NewSource(0)))
+	assert.NoError(t, err)
+	gs.ActivePlayer = 2
+	gs.EliminatedPlayers[3] = true
+	gs.Discards[0] = rules.Stack{rules.Priest}
+	gs.LastPlay[0] = rules.Priest
+
+	simple := NewSimple(gs)
+
+	assert.Equal(t, 2, simple.OpponentOffset)
+	assert.Equal(t, rules.Priest, simple.OpponentCard)
+	assert.Equal(t, -2, simple.ScoreDiff)
+}
+
+func TestSimpleActionTargetsOpponent(t *testing.T) {
+	simple := Simple{OpponentOffset: 3}
+	act := rules.Action{PlayRecent: true, TargetPlayerOffset: 1}
+
+	targeted := simple.TargetOpponent(act)
+	assert.Equal(t, 3, targeted.TargetPlayerOffset)
+
+	sa, _ := simple.AsIndexWithAction(targeted)
+	expected, _ := simple.AsIndexWithAction(act)
+	assert.Equal(t, expected, sa)
+}
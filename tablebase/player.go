@@ -0,0 +1,101 @@
+package tablebase
+
+import (
+	"math/rand"
+
+	"love-letter-ai/players"
+	"love-letter-ai/rules"
+	"love-letter-ai/state"
+)
+
+// tolerance is how much worse than the best action an action can be and still be counted as the best, since values
+// are summed in a different order for different actions.
+const tolerance = 1e-5
+
+// Player plays the best action in the tablebase when there are few enough cards left to draw, and lets Fallback play
+// otherwise. It assumes a 2-player classic game.
+type Player struct {
+	*Tablebase
+	Fallback players.Player
+}
+
+func (pl Player) PlayCard(st state.Simple) rules.Action {
+	if act, ok := pl.BestAction(st); ok {
+		return act
+	}
+	return pl.Fallback.PlayCard(st)
+}
+
+func (pl Player) PlayCardRand(st state.Simple, r *rand.Rand) rules.Action {
+	if act, ok := pl.BestAction(st); ok {
+		return act
+	}
+	return pl.Fallback.PlayCardRand(st, r)
+}
+
+// BestAction returns the active player's best action by the perfect-information average (see Tablebase.Solve),
+// without knowing the opponent's card, and true if the position is covered by the tablebase.
+func (tb *Tablebase) BestAction(st state.Simple) (rules.Action, bool) {
+	pos, err := SimplePosition(st)
+	if err != nil || pos.DrawPileSize() > tb.MaxDrawPile {
+		return rules.Action{}, false
+	}
+	entry, err := tb.Solve(pos)
+	if err != nil {
+		return rules.Action{}, false
+	}
+	best, _ := entry.Best()
+
+	// Any card the opponent might hold gives the same legal actions
+	for card, count := range pos.Deck {
+		if count > 0 {
+			game, err := pos.game(rules.Card(card))
+			if err != nil {
+				return rules.Action{}, false
+			}
+			for _, act := range game.LegalActions() {
+				if act.AsInt() == best {
+					return st.TargetOpponent(act), true
+				}
+			}
+			break
+		}
+	}
+	return rules.Action{}, false
+}
+
+// Agreement plays games of the player against itself, and counts how often it plays a best action by the
+// perfect-information average (see Tablebase.Solve) in positions the tablebase covers.
+func Agreement(tb *Tablebase, pl players.Player, games int, r *rand.Rand) (optimal, total int, err error) {
+	for i := 0; i < games; i++ {
+		game, err := rules.NewGame(2, r)
+		if err != nil {
+			return optimal, total, err
+		}
+		for !game.GameEnded {
+			var action rules.Action
+			if vp, ok := pl.(players.ViewPlayer); ok {
+				action = vp.PlayView(game.ViewFor(game.ActivePlayer))
+			} else {
+				action = pl.PlayCardRand(state.NewSimple(game), r)
+			}
+			if game.DrawPileSize() <= tb.MaxDrawPile {
+				pos, err := HiddenPosition(game)
+				if err != nil {
+					return optimal, total, err
+				}
+				entry, err := tb.Solve(pos)
+				if err != nil {
+					return optimal, total, err
+				}
+				_, best := entry.Best()
+				if value := entry[action.AsInt()]; value != Illegal && value >= best-tolerance {
+					optimal++
+				}
+				total++
+			}
+			game.PlayCard(action, r)
+		}
+	}
+	return optimal, total, nil
+}
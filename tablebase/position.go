@@ -0,0 +1,245 @@
+package tablebase
+
+import (
+	"errors"
+	"fmt"
+
+	"love-letter-ai/rules"
+	"love-letter-ai/state"
+)
+
+// Position is everything about a 2-player classic game that can change its result, from the active player's side.
+// The order of the cards that are left is unknown, so Deck only counts them.
+type Position struct {
+	// Deck contains the cards that can still be drawn, including the face-down card. If the opponent's card is hidden,
+	// it's also in Deck.
+	Deck rules.Deck
+
+	// Recent is the card the active player just drew, and Old is the card they already had.
+	Recent, Old rules.Card
+
+	// Opponent is the opponent's card, or None if it's hidden.
+	Opponent rules.Card
+
+	// Protected is true if the opponent can't be targeted (i.e. their last play was a Handmaid).
+	Protected bool
+
+	// ScoreDiff is the active player's discard score minus the opponent's, which breaks ties at the end.
+	ScoreDiff int
+}
+
+// PerfectPosition returns the position of the game with the opponent's card visible.
+func PerfectPosition(game rules.Gamestate) (Position, error) {
+	if err := checkGame(game); err != nil {
+		return Position{}, err
+	}
+	opponent := 1 - game.ActivePlayer
+	return Position{
+		Deck:      game.Deck,
+		Recent:    game.ActivePlayerCard,
+		Old:       game.CardInHand[game.ActivePlayer],
+		Opponent:  game.CardInHand[opponent],
+		Protected: game.LastPlay[opponent] == rules.Handmaid,
+		ScoreDiff: game.Discards[game.ActivePlayer].Score() - game.Discards[opponent].Score(),
+	}, nil
+}
+
+// HiddenPosition returns the position of the game as the active player sees it, without the opponent's card.
+// Whatever the active player learned about the card (e.g. with a Priest) is ignored.
+func HiddenPosition(game rules.Gamestate) (Position, error) {
+	pos, err := PerfectPosition(game)
+	if err != nil {
+		return pos, err
+	}
+	pos.Deck[pos.Opponent]++
+	pos.Opponent = rules.None
+	return pos, nil
+}
+
+// SimplePosition returns the position of a 2-player classic game described by a state.Simple, without the opponent's
+// card. The cards that can be drawn are every card that hasn't been seen.
+func SimplePosition(st state.Simple) (Position, error) {
+	if st.OpponentOffset != 1 {
+		return Position{}, errors.New("Only 2-player games have a position")
+	}
+	pos := Position{
+		Deck:      rules.ClassicCards.Deck(),
+		Recent:    st.RecentDraw,
+		Old:       st.OldCard,
+		Protected: st.OpponentCard == rules.Handmaid,
+		ScoreDiff: st.ScoreDiff,
+	}
+	pos.Deck[pos.Recent]--
+	pos.Deck[pos.Old]--
+	for card, count := range st.Discards {
+		pos.Deck[card] -= count
+	}
+	for _, count := range pos.Deck {
+		if count < 0 {
+			return Position{}, errors.New("The discards aren't from the classic cards")
+		}
+	}
+	return pos, nil
+}
+
+// checkGame returns an error unless the game is a 2-player classic game that is waiting for the active player to play.
+func checkGame(game rules.Gamestate) error {
+	if game.NumPlayers != 2 || (game.CardSet != nil && game.CardSet != rules.ClassicCards) {
+		return errors.New("Only 2-player games with the classic cards have a position")
+	}
+	if game.GameEnded || game.PendingCard != rules.None {
+		return errors.New("The game isn't waiting for the active player to play")
+	}
+	return nil
+}
+
+// Hidden returns true if the opponent's card is hidden.
+func (pos Position) Hidden() bool {
+	return pos.Opponent == rules.None
+}
+
+// DrawPileSize returns the number of cards that can still be drawn, not counting the face-down card.
+func (pos Position) DrawPileSize() int {
+	size := pos.Deck.Size() - 1
+	if pos.Hidden() {
+		size--
+	}
+	if size < 0 {
+		size = 0
+	}
+	return size
+}
+
+// Key returns a number that is the same for every position with the same result, once it's canonical.
+// It uses 32 bits: 12 for the deck, 4 for each card, 1 for Protected, and 7 for the score difference.
+func (pos Position) Key() uint64 {
+	key := uint64(pos.Deck.AsInt())
+	key = key<<4 + uint64(pos.Recent)
+	key = key<<4 + uint64(pos.Old)
+	key = key<<4 + uint64(pos.Opponent)
+	key <<= 1
+	if pos.Protected {
+		key++
+	}
+	return key<<7 + uint64(pos.ScoreDiff+64)
+}
+
+// canonical returns the position with the higher card as Recent, and a score difference no larger than the cards
+// left could change, so that positions with the same result have the same Key. It also returns true if the cards in
+// hand were swapped, since actions then play the other card.
+func (pos Position) canonical() (Position, bool) {
+	swapped := pos.Recent < pos.Old
+	if swapped {
+		pos.Recent, pos.Old = pos.Old, pos.Recent
+	}
+
+	// Once no discards could overcome the difference, a larger one makes no difference
+	bound := int(pos.Recent+pos.Old+pos.Opponent) + 1
+	for card, count := range pos.Deck {
+		bound += card * count
+	}
+	if pos.ScoreDiff > bound {
+		pos.ScoreDiff = bound
+	} else if pos.ScoreDiff < -bound {
+		pos.ScoreDiff = -bound
+	}
+	return pos, swapped
+}
+
+// game returns a game in this position, with the opponent holding the provided card. Player 0 is the active player.
+// Every other classic card has been seen, so the cards are split between the players' discards (making up the score
+// difference, and ending with the opponent's Handmaid if they're protected) and the face-up cards.
+func (pos Position) game(opponent rules.Card) (rules.Gamestate, error) {
+	deck := pos.Deck
+	if pos.Hidden() {
+		deck[opponent]--
+	}
+	seen := rules.ClassicCards.Deck()
+	for card, count := range deck {
+		seen[card] -= count
+	}
+	for _, card := range []rules.Card{pos.Recent, pos.Old, opponent} {
+		seen[card]--
+	}
+	for _, count := range seen {
+		if count < 0 {
+			return rules.Gamestate{}, errors.New("The position has more cards than the classic cards")
+		}
+	}
+
+	diff := pos.ScoreDiff
+	protection := rules.Stack{}
+	if pos.Protected {
+		if seen[rules.Handmaid] == 0 {
+			return rules.Gamestate{}, errors.New("The opponent is protected, but every Handmaid is somewhere else")
+		}
+		seen[rules.Handmaid]--
+		protection = rules.Stack{rules.Handmaid}
+		diff += int(rules.Handmaid)
+	}
+	mine, theirs, faceup, ok := splitSeen(seen.AsStack(), diff)
+	if !ok {
+		return rules.Gamestate{}, fmt.Errorf("No discards of the cards that have been seen make a score difference of %d", pos.ScoreDiff)
+	}
+	theirs = append(theirs, protection...)
+
+	game := rules.NewSimpleGame(rules.Deck{}, nil)
+	game.Deck = deck
+	game.Faceup = faceup
+	game.ActivePlayerCard = pos.Recent
+	game.CardInHand[0] = pos.Old
+	game.CardInHand[1] = opponent
+	game.Discards[0] = mine
+	game.Discards[1] = theirs
+	game.LastPlay[0] = lastPlay(mine)
+	game.LastPlay[1] = lastPlay(theirs)
+	if pos.Protected {
+		game.LastPlay[1] = rules.Handmaid
+	}
+	return game, nil
+}
+
+// splitSeen divides the cards between the active player's discards, the opponent's discards and the face-up cards,
+// so that the active player's discards score diff more than the opponent's. It returns false if that's impossible.
+func splitSeen(cards rules.Stack, diff int) (mine, theirs, faceup rules.Stack, ok bool) {
+	// impossible contains the (card index, difference) pairs that can't be made from the rest of the cards
+	impossible := map[[2]int]bool{}
+	var split func(i, diff int) bool
+	split = func(i, diff int) bool {
+		if i == len(cards) {
+			return diff == 0
+		}
+		if impossible[[2]int{i, diff}] {
+			return false
+		}
+		card := cards[i]
+		switch {
+		case split(i+1, diff):
+			faceup = append(faceup, card)
+		case split(i+1, diff-int(card)):
+			mine = append(mine, card)
+		case split(i+1, diff+int(card)):
+			theirs = append(theirs, card)
+		default:
+			impossible[[2]int{i, diff}] = true
+			return false
+		}
+		return true
+	}
+	mine, theirs, faceup = rules.Stack{}, rules.Stack{}, rules.Stack{}
+	ok = split(0, diff)
+	return mine, theirs, faceup, ok
+}
+
+// lastPlay moves a card other than a Handmaid to the end of the discards, so the player isn't protected, and returns
+// it. It returns None if there's no such card.
+func lastPlay(discards rules.Stack) rules.Card {
+	for i, card := range discards {
+		if card != rules.Handmaid {
+			last := len(discards) - 1
+			discards[i], discards[last] = discards[last], discards[i]
+			return discards[last]
+		}
+	}
+	return rules.None
+}
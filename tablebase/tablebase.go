@@ -0,0 +1,254 @@
+// Package tablebase solves 2-player classic positions with only a few cards left to draw, and stores the results so
+// agents and analysis tools can look them up.
+package tablebase
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+
+	"love-letter-ai/rules"
+)
+
+// Illegal is the value of an action that can't be played.
+const Illegal = -1
+
+// Entry contains the active player's probability of winning after each action, indexed by rules.Action.AsInt.
+// Actions that can't be played are Illegal. A shared win counts as half a win.
+type Entry [16]float32
+
+func newEntry() Entry {
+	entry := Entry{}
+	for i := range entry {
+		entry[i] = Illegal
+	}
+	return entry
+}
+
+// Best returns the index of the action with the highest value, and its value. Ties are broken by choosing the first.
+func (entry Entry) Best() (int, float32) {
+	best := -1
+	value := float32(Illegal)
+	for act, val := range entry {
+		if val > value {
+			best, value = act, val
+		}
+	}
+	return best, value
+}
+
+// swapped returns the entry for the same position with the cards in hand swapped.
+func (entry Entry) swapped() Entry {
+	for act := 0; act < len(entry); act += 2 {
+		entry[act], entry[act+1] = entry[act+1], entry[act]
+	}
+	return entry
+}
+
+// Tablebase contains the solved positions with at most MaxDrawPile cards left to draw. Positions are solved the
+// first time they're needed, so it can be generated by solving the positions of interest, and then saved.
+// It isn't safe for concurrent use.
+type Tablebase struct {
+	MaxDrawPile int
+
+	entries map[uint64]Entry
+}
+
+// New returns an empty tablebase for positions with at most maxDrawPile cards left to draw.
+func New(maxDrawPile int) *Tablebase {
+	return &Tablebase{
+		MaxDrawPile: maxDrawPile,
+		entries:     map[uint64]Entry{},
+	}
+}
+
+// Len returns the number of positions in the tablebase.
+func (tb *Tablebase) Len() int {
+	return len(tb.entries)
+}
+
+// Lookup returns the entry for the position if it has been solved.
+func (tb *Tablebase) Lookup(pos Position) (Entry, bool) {
+	canon, swapped := pos.canonical()
+	entry, ok := tb.entries[canon.Key()]
+	if swapped {
+		entry = entry.swapped()
+	}
+	return entry, ok
+}
+
+func (tb *Tablebase) store(pos Position, entry Entry) {
+	canon, swapped := pos.canonical()
+	if swapped {
+		entry = entry.swapped()
+	}
+	tb.entries[canon.Key()] = entry
+}
+
+// Solve returns the entry for the position, solving it if necessary.
+// If the opponent's card is visible, both players are assumed to know each other's cards for the rest of the game, and
+// to play perfectly. If it's hidden, the entry is the perfect-information average: the active player's chance of
+// winning after each action, averaged over every card the opponent might have, with both players knowing each other's
+// cards after the action. It's an estimate for choosing without knowing the opponent's card, not the value of the
+// hidden-information game, which would need both players' strategies over what they don't know.
+func (tb *Tablebase) Solve(pos Position) (Entry, error) {
+	if size := pos.DrawPileSize(); size > tb.MaxDrawPile {
+		return Entry{}, fmt.Errorf("The position has %d cards left to draw, but the tablebase only has %d", size, tb.MaxDrawPile)
+	}
+	if entry, ok := tb.Lookup(pos); ok {
+		return entry, nil
+	}
+	if !pos.Hidden() {
+		game, err := pos.game(pos.Opponent)
+		if err != nil {
+			return Entry{}, err
+		}
+		return tb.solve(&game), nil
+	}
+
+	entry := newEntry()
+	pool := pos.Deck.Size()
+	for card, count := range pos.Deck {
+		if count == 0 {
+			continue
+		}
+		game, err := pos.game(rules.Card(card))
+		if err != nil {
+			return Entry{}, err
+		}
+		for act, val := range tb.solve(&game) {
+			if val == Illegal {
+				continue
+			}
+			if entry[act] == Illegal {
+				entry[act] = 0
+			}
+			entry[act] += val * float32(count) / float32(pool)
+		}
+	}
+	tb.store(pos, entry)
+	return entry, nil
+}
+
+// SolveGame returns the entry for the game's position, with the opponent's card visible.
+func (tb *Tablebase) SolveGame(game rules.Gamestate) (Entry, error) {
+	pos, err := PerfectPosition(game)
+	if err != nil {
+		return Entry{}, err
+	}
+	return tb.Solve(pos)
+}
+
+// solve returns the entry for a game with perfect information, solving every position it can lead to.
+func (tb *Tablebase) solve(game *rules.Gamestate) Entry {
+	pos, _ := PerfectPosition(*game)
+	if entry, ok := tb.Lookup(pos); ok {
+		return entry
+	}
+
+	entry := newEntry()
+	player := game.ActivePlayer
+	for _, action := range game.LegalActions() {
+		value := 0.0
+		for _, outcome := range game.Outcomes(action) {
+			value += outcome.Probability * float64(tb.winProbability(&outcome.Next, player))
+		}
+		entry[action.AsInt()] = float32(value)
+	}
+	tb.store(pos, entry)
+	return entry
+}
+
+// winProbability returns the player's chance of winning the game, if both players play perfectly.
+func (tb *Tablebase) winProbability(game *rules.Gamestate, player int) float32 {
+	if game.GameEnded {
+		if !game.IsWinner(player) {
+			return 0
+		}
+		return 1 / float32(len(game.Winners))
+	}
+	_, value := tb.solve(game).Best()
+	if game.ActivePlayer != player {
+		return 1 - value
+	}
+	return value
+}
+
+type fileHeader struct {
+	Version     uint32
+	MaxDrawPile uint32
+	Entries     uint64
+}
+
+const currentFileFormatVersion = 1
+
+// SaveToFile writes every position in the tablebase to the file.
+func (tb *Tablebase) SaveToFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	err = binary.Write(writer, binary.BigEndian, fileHeader{
+		Version:     currentFileFormatVersion,
+		MaxDrawPile: uint32(tb.MaxDrawPile),
+		Entries:     uint64(len(tb.entries)),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sorting the keys means the same tablebase is always saved the same way
+	keys := make([]uint64, 0, len(tb.entries))
+	for key := range tb.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		if err := binary.Write(writer, binary.BigEndian, key); err != nil {
+			return err
+		}
+		if err := binary.Write(writer, binary.BigEndian, tb.entries[key]); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// LoadFromFile replaces the tablebase with the one in the file.
+func (tb *Tablebase) LoadFromFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := fileHeader{}
+	if err := binary.Read(reader, binary.BigEndian, &header); err != nil {
+		return err
+	}
+	if header.Version != currentFileFormatVersion {
+		return fmt.Errorf("Cannot load a tablebase from version not %d (%d)", currentFileFormatVersion, header.Version)
+	}
+
+	entries := make(map[uint64]Entry, header.Entries)
+	for i := uint64(0); i < header.Entries; i++ {
+		var key uint64
+		var entry Entry
+		if err := binary.Read(reader, binary.BigEndian, &key); err != nil {
+			return err
+		}
+		if err := binary.Read(reader, binary.BigEndian, &entry); err != nil {
+			return err
+		}
+		entries[key] = entry
+	}
+	tb.MaxDrawPile = int(header.MaxDrawPile)
+	tb.entries = entries
+	return nil
+}
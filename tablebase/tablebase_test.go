@@ -0,0 +1,140 @@
+package tablebase
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"love-letter-ai/players"
+	"love-letter-ai/rules"
+	"love-letter-ai/state"
+)
+
+func TestSolvePerfectPosition(t *testing.T) {
+	tb := New(0)
+	entry, err := tb.Solve(Position{
+		Deck:     rules.Deck{rules.Baron: 1},
+		Recent:   rules.Guard,
+		Old:      rules.Princess,
+		Opponent: rules.King,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, float32(0), entry[rules.Action{PlayRecent: false}.AsInt()], "Discarding the Princess loses")
+	for _, guess := range []rules.Card{rules.Priest, rules.King} {
+		act := rules.Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: guess}
+		assert.Equal(t, float32(1), entry[act.AsInt()], "Keeping the Princess wins, whatever the guess")
+	}
+	assert.Equal(t, float32(Illegal), entry[rules.Action{PlayRecent: true}.AsInt()], "The Guard has to target the opponent")
+}
+
+func TestSolveHiddenPosition(t *testing.T) {
+	tb := New(0)
+	pos := Position{
+		Deck:   rules.Deck{rules.Priest: 1, rules.King: 1},
+		Recent: rules.Guard,
+		Old:    rules.Baron,
+	}
+	entry, err := tb.Solve(pos)
+	assert.NoError(t, err)
+
+	// Guessing King either wins straight away, or leaves the Baron against a Priest
+	guessKing := rules.Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: rules.King}
+	guessPriest := rules.Action{PlayRecent: true, TargetPlayerOffset: 1, SelectedCard: rules.Priest}
+	baron := rules.Action{PlayRecent: false, TargetPlayerOffset: 1}
+	assert.InDelta(t, 1, entry[guessKing.AsInt()], 1e-6)
+	assert.InDelta(t, 0.5, entry[guessPriest.AsInt()], 1e-6)
+	assert.InDelta(t, 0, entry[baron.AsInt()], 1e-6)
+	best, _ := entry.Best()
+	assert.Equal(t, guessKing.AsInt(), best)
+
+	// The same hand in the other order is the same position, with the actions swapped
+	pos.Recent, pos.Old = pos.Old, pos.Recent
+	swapped, ok := tb.Lookup(pos)
+	assert.True(t, ok)
+	guessKing.PlayRecent = false
+	assert.InDelta(t, 1, swapped[guessKing.AsInt()], 1e-6)
+}
+
+func TestSolveRejectsLargePositions(t *testing.T) {
+	game, _ := rules.NewGame(2, rand.New(rand.NewSource(1)))
+	_, err := New(3).SolveGame(game)
+	assert.Error(t, err)
+
+	game, _ = rules.NewGame(3, rand.New(rand.NewSource(1)))
+	_, err = New(20).SolveGame(game)
+	assert.Error(t, err)
+}
+
+func TestPositionsAgree(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 20; i++ {
+		game, _ := rules.NewGame(2, r)
+		for !game.GameEnded {
+			hidden, err := HiddenPosition(game)
+			assert.NoError(t, err)
+			simple, err := SimplePosition(state.NewSimple(game))
+			assert.NoError(t, err)
+			assert.Equal(t, hidden, simple)
+			assert.Equal(t, game.DrawPileSize(), hidden.DrawPileSize())
+
+			perfect, _ := PerfectPosition(game)
+			built, err := perfect.game(perfect.Opponent)
+			assert.NoError(t, err)
+			assert.NoError(t, built.Validate())
+			rebuilt, _ := PerfectPosition(built)
+			assert.Equal(t, perfect, rebuilt)
+			for card, count := range hidden.Deck {
+				if count > 0 {
+					built, err := hidden.game(rules.Card(card))
+					assert.NoError(t, err)
+					assert.NoError(t, built.Validate(), "%+v with the opponent holding %v", hidden, rules.Card(card))
+				}
+			}
+
+			acts := game.LegalActions()
+			game.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+	}
+}
+
+func TestTablebasePlayerIsOptimal(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	tb := New(2)
+	pl := Player{Tablebase: tb, Fallback: &players.RandomPlayer{}}
+	optimal, total, err := Agreement(tb, pl, 50, r)
+	assert.NoError(t, err)
+	assert.True(t, total > 0)
+	assert.Equal(t, total, optimal)
+
+	optimal, total, err = Agreement(tb, &players.RandomPlayer{}, 50, r)
+	assert.NoError(t, err)
+	assert.True(t, optimal < total, "A random player isn't always optimal")
+}
+
+func TestFileLoadSave(t *testing.T) {
+	path := "temp-tablebase-test-file.dat"
+	r := rand.New(rand.NewSource(7))
+	tb := New(1)
+	for i := 0; i < 20; i++ {
+		game, _ := rules.NewGame(2, r)
+		for !game.GameEnded && game.DrawPileSize() > 1 {
+			acts := game.LegalActions()
+			game.PlayCard(acts[r.Intn(len(acts))], r)
+		}
+		if !game.GameEnded {
+			_, err := tb.SolveGame(game)
+			assert.NoError(t, err)
+		}
+	}
+
+	err := tb.SaveToFile(path)
+	defer os.Remove(path)
+	assert.NoError(t, err)
+
+	tb2 := New(0)
+	assert.NoError(t, tb2.LoadFromFile(path))
+	assert.Equal(t, tb, tb2)
+}
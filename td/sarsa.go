@@ -1,9 +1,11 @@
 package td
 
 import (
+	"math"
 	"math/rand"
 
 	"love-letter-ai/players"
+	"love-letter-ai/qtable"
 	"love-letter-ai/rules"
 	"love-letter-ai/state"
 )
@@ -16,15 +18,16 @@ type sarsaLearner struct{ TD }
 
 func (lrn sarsaLearner) Finalize() {}
 
-func (sl sarsaLearner) UpdateQ(gameEnded bool, qStates []int, rewards []float32) {
+func (sl sarsaLearner) UpdateQ(gameEnded bool, qStates []int, masks []state.ActionMask, rewards []float32) {
 	lastQ, thisQ := qStates[len(qStates)-2], qStates[len(qStates)-1]
 	reward := rewards[len(rewards)-1]
 
 	thisValue := float32(0) // If game ended, the value of the new state is 0 because it's a terminal state
 	if !gameEnded {
-		thisValue = sl.Gamma * sl.qf[thisQ]
+		thisValue = sl.Gamma * sl.Value(thisQ)
 	}
-	sl.qf[lastQ] += sl.Alpha * (reward + thisValue - sl.qf[lastQ])
+	lastValue := sl.Value(lastQ)
+	sl.setQ(lastQ, lastValue+sl.Alpha*(reward+thisValue-lastValue))
 }
 
 func (td TD) QLearner() players.TrainingPlayer {
@@ -35,27 +38,28 @@ type qLearner struct{ TD }
 
 func (lrn qLearner) Finalize() {}
 
-func (lrn qLearner) UpdateQ(gameEnded bool, qStates []int, rewards []float32) {
+func (lrn qLearner) UpdateQ(gameEnded bool, qStates []int, masks []state.ActionMask, rewards []float32) {
 	lastQ, thisQ := qStates[len(qStates)-2], qStates[len(qStates)-1]
 	reward := rewards[len(rewards)-1]
 
-	// The expected value is the greedy policy.
+	// The expected value is the greedy policy, out of the legal actions.
 	thisValue := float32(0) // If game ended, the value of the new state is 0 because it's a terminal state
 	if !gameEnded {
-		st := state.IndexWithoutAction(thisQ)
-		act, greedySA := lrn.GreedyAction(st)
+		st := thisQ % lrn.encoder.Size()
+		act, greedySA := lrn.GreedyAction(st, masks[len(masks)-1])
 		if act == nil {
 			// We don't have enough data to know what's greedy. I'm not sure if this is common or impossible.
 			greedySA = thisQ
 		}
-		thisValue = lrn.Gamma * lrn.qf[greedySA]
+		thisValue = lrn.Gamma * lrn.Value(greedySA)
 	}
-	lrn.qf[lastQ] += lrn.Alpha * (reward + thisValue - lrn.qf[lastQ])
+	lastValue := lrn.Value(lastQ)
+	lrn.setQ(lastQ, lastValue+lrn.Alpha*(reward+thisValue-lastValue))
 }
 
 func (td TD) DoubleQLearner() players.TrainingPlayer {
 	return doubleQLearner{
-		td: []TD{td, *NewTD(td.Alpha, td.Gamma)},
+		td: []TD{td, *NewTDWithMemory(td.encoder, td.Alpha, td.Gamma, td.maxBytes)},
 	}
 }
 
@@ -64,10 +68,12 @@ type doubleQLearner struct {
 	selectFirst bool
 }
 
+// Finalize averages the two estimates into the first. (Averaging rather than summing keeps the values that were never
+// learned at their initial value, which sparse tables don't store.)
 func (lrn doubleQLearner) Finalize() {
-	for i := range lrn.td[0].qf {
-		lrn.td[0].qf[i] += lrn.td[1].qf[i]
-	}
+	qtable.Merge(lrn.td[0].qf, lrn.td[1].qf, func(a, b uint32) uint32 {
+		return math.Float32bits((math.Float32frombits(a) + math.Float32frombits(b)) / 2)
+	})
 }
 
 func (lrn doubleQLearner) randTD() int                 { return rand.Int() % 2 }
@@ -81,29 +87,34 @@ func (lrn doubleQLearner) PlayCardRand(st state.Simple, r *rand.Rand) rules.Acti
 	return lrn.td[lrn.randTDRand(r)].PlayCard(st)
 }
 
-func (lrn doubleQLearner) GreedyAction(state int) (*rules.Action, int) {
-	return lrn.td[lrn.randTD()].GreedyAction(state)
+func (lrn doubleQLearner) Encoder() state.StateEncoder {
+	return lrn.td[0].encoder
+}
+
+func (lrn doubleQLearner) GreedyAction(state int, mask state.ActionMask) (*rules.Action, int) {
+	return lrn.td[lrn.randTD()].GreedyAction(state, mask)
 }
 
-func (lrn doubleQLearner) UpdateQ(gameEnded bool, qStates []int, rewards []float32) {
+func (lrn doubleQLearner) UpdateQ(gameEnded bool, qStates []int, masks []state.ActionMask, rewards []float32) {
 	lastQ, thisQ := qStates[len(qStates)-2], qStates[len(qStates)-1]
 	reward := rewards[len(rewards)-1]
 
 	pick := lrn.randTD()
-	lrn.updateQ(lrn.td[pick], lrn.td[(pick+1)%2], gameEnded, lastQ, thisQ, reward)
+	lrn.updateQ(lrn.td[pick], lrn.td[(pick+1)%2], gameEnded, lastQ, thisQ, masks[len(masks)-1], reward)
 }
 
-func (lrn doubleQLearner) updateQ(a, b TD, gameEnded bool, lastQ, thisQ int, reward float32) {
-	// The expected value is the greedy policy.
+func (lrn doubleQLearner) updateQ(a, b TD, gameEnded bool, lastQ, thisQ int, mask state.ActionMask, reward float32) {
+	// The expected value is the greedy policy, out of the legal actions.
 	thisValue := float32(0) // If game ended, the value of the new state is 0 because it's a terminal state
 	if !gameEnded {
-		st := state.IndexWithoutAction(thisQ)
-		act, greedySA := a.GreedyAction(st)
+		st := thisQ % a.encoder.Size()
+		act, greedySA := a.GreedyAction(st, mask)
 		if act == nil {
 			// We don't have enough data to know what's greedy. I'm not sure if this is common or impossible.
 			greedySA = thisQ
 		}
-		thisValue = a.Gamma * b.qf[greedySA]
+		thisValue = a.Gamma * b.Value(greedySA)
 	}
-	a.qf[lastQ] += a.Alpha * (reward + thisValue - a.qf[lastQ])
+	lastValue := a.Value(lastQ)
+	a.setQ(lastQ, lastValue+a.Alpha*(reward+thisValue-lastValue))
 }
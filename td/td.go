@@ -3,70 +3,125 @@ package td
 import (
 	"bufio"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"love-letter-ai/players"
+	"love-letter-ai/qtable"
 	"love-letter-ai/rules"
 	"love-letter-ai/state"
+	"math"
 	"math/rand"
 	"os"
 )
 
 type TD struct {
-	qf    []float32
-	Alpha float32
-	Gamma float32
+	qf       qtable.Table
+	encoder  state.StateEncoder
+	maxBytes int
+	Alpha    float32
+	Gamma    float32
 }
 
+// NewTD returns a TD that learns the value of each state-action from state.Simple.
 func NewTD(alpha, gamma float32) *TD {
+	return NewTDWithEncoder(state.SimpleEncoder{}, alpha, gamma)
+}
+
+// NewTDWithEncoder returns a TD that learns the value of each state-action from the encoder's states.
+func NewTDWithEncoder(enc state.StateEncoder, alpha, gamma float32) *TD {
+	return NewTDWithMemory(enc, alpha, gamma, 0)
+}
+
+// NewTDWithMemory returns a TD like NewTDWithEncoder whose values use at most about maxBytes (or 0 for no limit).
+// If every value doesn't fit, only the values that are learned are stored, until the limit is reached.
+func NewTDWithMemory(enc state.StateEncoder, alpha, gamma float32, maxBytes int) *TD {
 	sar := &TD{
-		qf:    make([]float32, state.ActionSpaceMagnitude, state.ActionSpaceMagnitude),
-		Alpha: alpha,
-		Gamma: gamma,
-	}
-	for i := range sar.qf {
-		sar.qf[i] = players.HalfWinReward
+		qf:       qtable.New(state.ActionSize(enc), math.Float32bits(players.HalfWinReward), maxBytes),
+		encoder:  enc,
+		maxBytes: maxBytes,
+		Alpha:    alpha,
+		Gamma:    gamma,
 	}
-	sar.qf[state.TerminalState] = 0
+	sar.setQ(enc.Size()-1, 0) // The terminal state
 	return sar
 }
 
+// Encoder returns the encoder for the states whose values are learned.
+func (sarsa TD) Encoder() state.StateEncoder {
+	return sarsa.encoder
+}
+
 func (sarsa TD) Value(actState int) float32 {
-	return sarsa.qf[actState]
+	return math.Float32frombits(sarsa.qf.Get(actState))
+}
+
+// setQ stores the value of the state-action, returning false if there's no room for it.
+func (sarsa TD) setQ(actState int, value float32) bool {
+	return sarsa.qf.Set(actState, math.Float32bits(value))
+}
+
+// Stored returns the number of values stored, and the number that were dropped because of the memory limit.
+func (sarsa TD) Stored() (int, int) {
+	return sarsa.qf.Stored(), sarsa.qf.Dropped()
 }
 
 // PlayCard provides a suggested action for the provided state.
-// If it hasn't learned anything for this state, it plays randomly.
+// If it hasn't learned anything for this state, it plays a random legal action. A state.Simple can only be encoded by
+// the state.SimpleEncoder, so with any other encoder it always plays randomly; use PlayView instead.
 func (sar TD) PlayCard(state state.Simple) rules.Action {
-	act, _ := sar.GreedyAction(state.AsIndex())
+	return sar.PlayCardRand(state, nil)
+}
+
+// PlayCardRand is the same as PlayCard, using r for random plays (or the default source if it's nil).
+func (sar TD) PlayCardRand(state state.Simple, r *rand.Rand) rules.Action {
+	mask := state.LegalMask()
+	act := sar.simpleAction(state, mask)
 	if act == nil {
-		return (&players.RandomPlayer{}).PlayCard(state)
+		return state.TargetOpponent(rules.ActionFromInt(mask.Random(r)))
 	}
-	return *act
+	return state.TargetOpponent(*act)
 }
 
-// PlayCard provides a suggested action for the provided state.
-// If it hasn't learned anything for this state, it plays randomly.
-func (sar TD) PlayCardRand(state state.Simple, r *rand.Rand) rules.Action {
-	act, _ := sar.GreedyAction(state.AsIndex())
+// simpleAction returns the greedy action for the state, or nil if it can't be encoded or nothing has been learned.
+func (sar TD) simpleAction(st state.Simple, mask state.ActionMask) *rules.Action {
+	if _, ok := sar.encoder.(state.SimpleEncoder); !ok {
+		return nil
+	}
+	act, _ := sar.GreedyAction(st.AsIndex(), mask)
+	return act
+}
+
+// PlayView provides a suggested action for the active player's view, using the encoder.
+// If it hasn't learned anything for this state, it plays a random legal action.
+func (sar TD) PlayView(view rules.PlayerView) rules.Action {
+	mask := state.EncodedMask(sar.encoder, view)
+	act, _ := sar.GreedyAction(sar.encoder.Index(view), mask)
 	if act == nil {
-		return (&players.RandomPlayer{}).PlayCardRand(state, r)
+		return state.DecodeAction(sar.encoder, view, rules.ActionFromInt(mask.Random(nil)))
 	}
-	return *act
+	return state.DecodeAction(sar.encoder, view, *act)
 }
 
-// greedyAction returns the greedy action for the given state. (Note the argument should be a state, not an action-state.)
+// GreedyAction returns the greedy action for the given state, out of the actions in the mask. (Note the argument
+// should be a state, not an action-state.)
 // Ties are broken by choosing the first option (i.e. arbitrarily in a deterministic way).
-func (sarsa TD) GreedyAction(st int) (*rules.Action, int) {
+func (sarsa TD) GreedyAction(st int, mask state.ActionMask) (*rules.Action, int) {
 	bestActs := []int{}
 	bestActValue := float32(0)
 	bestActState := 0
-	for act, actState := range state.AllActionStates(st) {
-		thisVal := sarsa.qf[actState]
+	for act, actState := range state.ActionStates(sarsa.encoder, st) {
+		if !mask.Has(act) {
+			continue
+		}
+		thisVal := sarsa.Value(actState)
 		if thisVal > bestActValue {
 			bestActValue = thisVal
 			bestActs = []int{act}
 			bestActState = actState
 		} else if thisVal == bestActValue {
+			if len(bestActs) == 0 {
+				bestActState = actState
+			}
 			bestActs = append(bestActs, act)
 		}
 	}
@@ -85,8 +140,12 @@ type fileHeader struct {
 	ActionSpaceMagnitude uint64
 }
 
-const currentFileFormatVersion = 2
+const (
+	denseFileFormatVersion  = 2 // Every value, in order
+	sparseFileFormatVersion = 3 // The number of values stored, then the index and value of each one
+)
 
+// SaveToFile saves the values. Dense tables save every value, and sparse tables only save the values stored.
 func (sarsa TD) SaveToFile(path string) error {
 	file, err := os.Create(path)
 	defer file.Close()
@@ -94,32 +153,53 @@ func (sarsa TD) SaveToFile(path string) error {
 		return err
 	}
 
-	length := len(sarsa.qf)
+	length := sarsa.qf.Len()
+	stored := sarsa.qf.Stored()
+	version := sparseFileFormatVersion
+	if _, ok := sarsa.qf.(qtable.Dense); ok {
+		version = denseFileFormatVersion
+	}
 	writer := bufio.NewWriter(file)
 
 	err = binary.Write(writer, binary.BigEndian, fileHeader{
-		Version:              currentFileFormatVersion,
+		Version:              uint32(version),
 		Alpha:                sarsa.Alpha,
 		Gamma:                sarsa.Gamma,
 		ActionSpaceMagnitude: uint64(length),
 	})
+	if err == nil && version == sparseFileFormatVersion {
+		err = binary.Write(writer, binary.BigEndian, uint64(stored))
+	}
 	if err != nil {
 		return err
 	}
 
-	for i, val := range sarsa.qf {
-		if i%(length/100) == 0 {
-			fmt.Printf("\rSaving %2d%%", i*100/length)
+	n := 0
+	sarsa.qf.Each(func(i int, val uint32) {
+		if err != nil {
+			return
 		}
-		if err := binary.Write(writer, binary.BigEndian, val); err != nil {
-			return err
+		if stored >= 100 && n%(stored/100) == 0 {
+			fmt.Printf("\rSaving %2d%%", n*100/stored)
+		}
+		n++
+		if version == sparseFileFormatVersion {
+			if err = binary.Write(writer, binary.BigEndian, uint32(i)); err != nil {
+				return
+			}
 		}
+		// The bits are written, which is the same as writing the float32
+		err = binary.Write(writer, binary.BigEndian, val)
+	})
+	if err != nil {
+		return err
 	}
 	fmt.Printf("\rSaved 100%%\n")
 
 	return writer.Flush()
 }
 
+// LoadFromFile loads values saved by SaveToFile into the table, whether it's dense or sparse.
 func (sarsa *TD) LoadFromFile(path string) error {
 	file, err := os.Open(path)
 	defer file.Close()
@@ -127,28 +207,53 @@ func (sarsa *TD) LoadFromFile(path string) error {
 		return err
 	}
 
-	length := len(sarsa.qf)
+	length := sarsa.qf.Len()
 
 	reader := bufio.NewReader(file)
 	header := &fileHeader{}
 	if err = binary.Read(reader, binary.BigEndian, header); err != nil {
 		return err
 	}
-	if header.Version != currentFileFormatVersion {
-		return fmt.Errorf("Cannot load SARSA weights from version not %d (%d)", currentFileFormatVersion, header.Version)
+	if header.Version != denseFileFormatVersion && header.Version != sparseFileFormatVersion {
+		return fmt.Errorf("Cannot load SARSA weights from version not %d or %d (%d)", denseFileFormatVersion, sparseFileFormatVersion, header.Version)
 	}
 	if int(header.ActionSpaceMagnitude) != length {
-		return fmt.Errorf("Cannot load SARSA weights from file size not %d (%d)", state.ActionSpaceMagnitude, header.ActionSpaceMagnitude)
+		return fmt.Errorf("Cannot load SARSA weights from file size not %d (%d)", length, header.ActionSpaceMagnitude)
 	}
 	sarsa.Alpha = header.Alpha
 	sarsa.Gamma = header.Gamma
 
-	for i := range sarsa.qf {
-		if i%(length/100) == 0 {
-			fmt.Printf("\rLoading %2d%%", i*100/length)
+	count := length
+	if header.Version == sparseFileFormatVersion {
+		var stored uint64
+		if err := binary.Read(reader, binary.BigEndian, &stored); err != nil {
+			return err
+		}
+		count = int(stored)
+	}
+
+	for n := 0; n < count; n++ {
+		if count >= 100 && n%(count/100) == 0 {
+			fmt.Printf("\rLoading %2d%%", n*100/count)
+		}
+		i := n
+		if header.Version == sparseFileFormatVersion {
+			var index uint32
+			if err := binary.Read(reader, binary.BigEndian, &index); err != nil {
+				return err
+			}
+			i = int(index)
+			if i >= length {
+				return fmt.Errorf("Cannot load SARSA weights with index %d out of range", i)
+			}
+		}
+		var val uint32
+		if err := binary.Read(reader, binary.BigEndian, &val); err != nil {
+			return err
 		}
-		if err := binary.Read(reader, binary.BigEndian, &sarsa.qf[i]); err != nil {
-			return nil
+		// Only values that were learned need to be stored
+		if val != sarsa.qf.Get(i) && !sarsa.qf.Set(i, val) {
+			return errors.New("The SARSA weights don't fit in the memory limit")
 		}
 	}
 	fmt.Printf("\rLoaded 100%%\n")
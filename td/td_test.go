@@ -4,6 +4,11 @@ import (
 	"os"
 	"testing"
 
+	"love-letter-ai/players"
+	"love-letter-ai/qtable"
+	"love-letter-ai/rules"
+	"love-letter-ai/state"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,9 +20,9 @@ func TestFileLoadSave(t *testing.T) {
 	td := newTestTDlayer(alpha, gamma, tableSize)
 
 	// To avoid using much RAM, only fill the first portion (but all will be written)
-	for i := range td.qf {
+	for i := 0; i < tableSize; i++ {
 		// Fill with arbitrary data
-		td.qf[i] = float32(i*208284) / 7282
+		td.setQ(i, float32(i*208284)/7282)
 	}
 
 	err := td.SaveToFile(path)
@@ -35,8 +40,124 @@ func TestFileLoadSave(t *testing.T) {
 
 func newTestTDlayer(alpha, gamma float32, size int) *TD {
 	return &TD{
-		qf:    make([]float32, size, size),
+		qf:    qtable.NewDense(size, 0),
 		Alpha: alpha,
 		Gamma: gamma,
 	}
 }
+
+// handEncoder only encodes the cards in hand, so tests can train quickly.
+type handEncoder struct{}
+
+func (handEncoder) Name() string { return "hand" }
+func (handEncoder) Size() int    { return 9 * 9 }
+
+func (handEncoder) Index(view rules.PlayerView) int {
+	simple := state.SimpleFromView(view)
+	return int(simple.RecentDraw)*9 + int(simple.OldCard)
+}
+
+func (enc handEncoder) Features(view rules.PlayerView) []float32 {
+	return []float32{float32(enc.Index(view))}
+}
+
+func TestTrainWithEncoder(t *testing.T) {
+	players.Output = false
+	sar := NewTDWithEncoder(handEncoder{}, 0.3, 1)
+	assert.Equal(t, 16*81, sar.qf.Len())
+
+	players.Train([]players.TrainingPlayer{sar.QLearner(), sar.QLearner()}, 200, 0.3)
+
+	changed := 0
+	for i := 0; i < sar.qf.Len()-1; i++ {
+		if sar.Value(i) != players.HalfWinReward {
+			changed++
+		}
+	}
+	assert.NotZero(t, changed, "No values were updated")
+}
+
+func TestTrainCanonical(t *testing.T) {
+	players.Output = false
+	enc := state.CanonicalEncoder{Encoder: handEncoder{}}
+	sar := NewTDWithEncoder(enc, 0.3, 1)
+	players.Train([]players.TrainingPlayer{sar.QLearner(), sar.QLearner()}, 200, 0.3)
+
+	// Only hands with the recent card no lower than the old card are learned
+	for recent := rules.Guard; recent <= rules.Princess; recent++ {
+		for old := recent + 1; old <= rules.Princess; old++ {
+			for _, sa := range state.ActionStates(enc, int(recent)*9+int(old)) {
+				assert.Equal(t, float32(players.HalfWinReward), sar.Value(sa), "%v, %v", recent, old)
+			}
+		}
+	}
+
+	// Mirrored hands play the same card
+	handmaid := rules.Action{PlayRecent: true}
+	sar.setQ(state.ActionStates(enc, int(rules.Handmaid)*9+int(rules.Baron))[handmaid.AsInt()], 1000)
+	drawn, err := rules.ParseScenario("p0: hand=Baron drawn=Handmaid; p1: hand=King")
+	assert.NoError(t, err)
+	kept, err := rules.ParseScenario("p0: hand=Handmaid drawn=Baron; p1: hand=King")
+	assert.NoError(t, err)
+	assert.Equal(t, handmaid.AsInt(), sar.PlayView(drawn.ViewFor(0)).AsInt())
+	assert.Equal(t, rules.Action{PlayRecent: false}.AsInt(), sar.PlayView(kept.ViewFor(0)).AsInt())
+}
+
+func TestSparseTD(t *testing.T) {
+	players.Output = false
+	path := "temp-td-sparse-test-file.dat"
+	defer os.Remove(path)
+
+	// The dense table would need 16*81*4 bytes
+	sparse := NewTDWithMemory(handEncoder{}, 0.3, 1, 4000)
+	assert.IsType(t, &qtable.Sparse{}, sparse.qf)
+	players.Train([]players.TrainingPlayer{sparse.DoubleQLearner(), sparse.DoubleQLearner()}, 200, 0.3)
+	stored, dropped := sparse.Stored()
+	assert.True(t, stored > 1 && stored < sparse.qf.Len(), "%d values were stored", stored)
+	assert.Zero(t, dropped)
+
+	// Sparse files load into dense tables, and the other way around
+	assert.NoError(t, sparse.SaveToFile(path))
+	dense := NewTDWithEncoder(handEncoder{}, 0, 0)
+	assert.NoError(t, dense.LoadFromFile(path))
+	assert.NoError(t, dense.SaveToFile(path))
+	loaded := NewTDWithMemory(handEncoder{}, 0, 0, 4000)
+	assert.NoError(t, loaded.LoadFromFile(path))
+
+	assert.Equal(t, float32(0.3), loaded.Alpha)
+	for i := 0; i < sparse.qf.Len(); i++ {
+		assert.Equal(t, sparse.Value(i), dense.Value(i), "Value %d", i)
+		assert.Equal(t, sparse.Value(i), loaded.Value(i), "Value %d", i)
+	}
+	// Values that are the same as the default don't need to be stored
+	storedAfterLoading, _ := loaded.Stored()
+	assert.True(t, storedAfterLoading <= stored)
+
+	// A limit too small for the weights fails to load
+	tiny := NewTDWithMemory(handEncoder{}, 0, 0, 1)
+	if stored > 64*16 {
+		assert.Error(t, tiny.LoadFromFile(path))
+	}
+}
+
+func TestGreedyActionIsMasked(t *testing.T) {
+	sar := NewTDWithEncoder(handEncoder{}, 0.3, 1)
+	st := int(rules.King)*9 + int(rules.Countess)
+	states := state.ActionStates(handEncoder{}, st)
+	mask := state.LegalMask(rules.King, rules.Countess)
+
+	// Playing the King is illegal, however valuable it looks
+	king := rules.Action{PlayRecent: true, TargetPlayerOffset: 1}
+	sar.setQ(states[king.AsInt()], 1000)
+	countess := rules.Action{PlayRecent: false}
+	sar.setQ(states[countess.AsInt()], 1)
+
+	act, sa := sar.GreedyAction(st, state.AllActions)
+	assert.Equal(t, king.AsInt(), act.AsInt())
+	act, sa = sar.GreedyAction(st, mask)
+	assert.Equal(t, countess.AsInt(), act.AsInt())
+	assert.Equal(t, states[countess.AsInt()], sa)
+
+	act, _ = sar.GreedyAction(st, 0)
+	assert.Nil(t, act, "Nothing can be chosen without legal actions")
+}